@@ -1,6 +1,7 @@
 package args
 
 import (
+	"path"
 	"strings"
 
 	"github.com/pborman/getopt/v2"
@@ -14,6 +15,12 @@ type Flags struct {
 	MonochromeMode bool
 	ShowUpdates    bool
 	ShowInstalled  bool
+	Fixtures       string
+	Installation   string
+	Command        string
+	StateFile      string
+	BundleFile     string
+	DryRun         bool
 	Help           bool
 }
 
@@ -25,6 +32,9 @@ func Parse() Flags {
 	mono := getopt.Bool('m', "Monochrome mode")
 	upd := getopt.Bool('u', "Show updates after startup")
 	inst := getopt.Bool('i', "Show installed packages after startup")
+	installation := getopt.StringLong("installation", 0, "", "Limit operations to an installation (user, system or a custom one)")
+	dryRun := getopt.BoolLong("dry-run", 0, "Only print the plan of apply")
+	fixtures := getopt.StringLong("fixtures", 0, "", "Use fixture files from a directory instead of flatpak")
 	help := getopt.BoolLong("help", 'h', "Show usage / help")
 	qhelp := getopt.BoolLong("?", '?', "Show usage / help")
 
@@ -35,12 +45,29 @@ func Parse() Flags {
 		}
 	}
 
+	// subcommands; options may follow them
+	positional := getopt.Args()
+	command := ""
+	if len(positional) > 0 && positional[0] == "apply" {
+		command = positional[0]
+		positional, err = parseAfterCommand(positional[1:])
+		if err != nil {
+			return Flags{
+				Help: true,
+			}
+		}
+	}
+
 	flags := Flags{
 		SearchTerm:     *term,
 		AsciiMode:      *ascii,
 		MonochromeMode: *mono,
 		ShowUpdates:    *upd,
 		ShowInstalled:  *inst,
+		Fixtures:       *fixtures,
+		Installation:   *installation,
+		Command:        command,
+		DryRun:         *dryRun,
 	}
 
 	if len(*repos) > 0 {
@@ -49,9 +76,41 @@ func Parse() Flags {
 
 	flags.Help = *help || *qhelp
 
-	if flags.SearchTerm == "" && len(getopt.Args()) > 0 {
-		flags.SearchTerm = getopt.Args()[0]
+	if command == "apply" {
+		if len(positional) != 1 {
+			flags.Help = true
+		} else {
+			flags.StateFile = positional[0]
+		}
+		return flags
+	}
+
+	// files we can install from are previewed instead of searched
+	if flags.SearchTerm == "" && len(positional) > 0 {
+		switch path.Ext(positional[0]) {
+		case ".flatpak", ".flatpakref", ".flatpakrepo":
+			flags.BundleFile = positional[0]
+		default:
+			flags.SearchTerm = positional[0]
+		}
 	}
 
 	return flags
 }
+
+// parses the options following a subcommand, which may be mixed with its arguments.
+// returns the arguments
+func parseAfterCommand(args []string) ([]string, error) {
+	positional := []string{}
+	for {
+		if err := getopt.CommandLine.Getopt(append([]string{"flatseek"}, args...), nil); err != nil {
+			return nil, err
+		}
+		args = getopt.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
@@ -21,6 +21,7 @@ type Settings struct {
 	InstallCommand          string
 	UninstallCommand        string
 	SysUpgradeCommand       string
+	Installation            string
 	SearchMode              string
 	SearchBy                string
 	CacheExpiry             int
@@ -54,10 +55,10 @@ func Defaults() *Settings {
 		MaxResults:             500,
 		PacmanDbPath:           "/var/lib/pacman/",
 		PacmanConfigPath:       "/etc/pacman.conf",
-		InstallCommand:         "yay -S",
-		UninstallCommand:       "yay -Rs",
+		InstallCommand:         "flatpak install {installation} {remote} {ref}",
+		UninstallCommand:       "flatpak uninstall {installation} {ref}",
 		SearchMode:             "Contains",
-		SysUpgradeCommand:      "yay",
+		SysUpgradeCommand:      "flatpak update {installation}",
 		SearchBy:               "Name",
 		CacheExpiry:            10,
 		DisableCache:           false,
@@ -200,6 +201,20 @@ func (s *Settings) applyUpgradeFixes() {
 		fixApplied = true
 	}
 
+	// flatpak commands replacing the pacman ones
+	if s.InstallCommand == "yay -S" {
+		s.InstallCommand = def.InstallCommand
+		fixApplied = true
+	}
+	if s.UninstallCommand == "yay -Rs" {
+		s.UninstallCommand = def.UninstallCommand
+		fixApplied = true
+	}
+	if s.SysUpgradeCommand == "yay" {
+		s.SysUpgradeCommand = def.SysUpgradeCommand
+		fixApplied = true
+	}
+
 	// save config file when we applied changes
 	if fixApplied {
 		s.Save()
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+)
+
+// LaunchProfile holds the options an app is run with
+type LaunchProfile struct {
+	Name        string
+	Branch      string
+	Command     string
+	Args        string
+	Environment string
+	Devel       bool
+	Shell       bool
+}
+
+// LaunchProfiles are the saved launch profiles of all apps, keyed by app id
+type LaunchProfiles map[string][]LaunchProfile
+
+// returns the path of our profiles file ~/.config/flatseek/profiles.json
+func profilesFile() (string, error) {
+	confPath, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return path.Join(confPath, "/flatseek/profiles.json"), nil
+}
+
+// LoadLaunchProfiles is loading the launch profiles from the profiles file
+func LoadLaunchProfiles() (LaunchProfiles, error) {
+	profiles := LaunchProfiles{}
+	file, err := profilesFile()
+	if err != nil {
+		return profiles, err
+	}
+
+	b, err := os.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return profiles, nil
+	} else if err != nil {
+		return profiles, err
+	}
+	if err = json.Unmarshal(b, &profiles); err != nil {
+		return LaunchProfiles{}, err
+	}
+	return profiles, nil
+}
+
+// Save is creating / overwriting the profiles file next to our config file
+func (p LaunchProfiles) Save() error {
+	b, err := json.MarshalIndent(p, "", "	")
+	if err != nil {
+		return err
+	}
+
+	file, err := profilesFile()
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(path.Dir(file), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(file, b, 0644)
+}
+
+// Set adds or replaces the profile with the same name of an app
+func (p LaunchProfiles) Set(appID string, profile LaunchProfile) {
+	for i, existing := range p[appID] {
+		if existing.Name == profile.Name {
+			p[appID][i] = profile
+			return
+		}
+	}
+	p[appID] = append(p[appID], profile)
+	sort.Slice(p[appID], func(i, j int) bool {
+		return p[appID][i].Name < p[appID][j].Name
+	})
+}
+
+// Remove deletes the profile with the given name of an app
+func (p LaunchProfiles) Remove(appID, name string) {
+	for i, existing := range p[appID] {
+		if existing.Name == name {
+			p[appID] = append(p[appID][:i], p[appID][i+1:]...)
+			break
+		}
+	}
+	if len(p[appID]) == 0 {
+		delete(p, appID)
+	}
+}
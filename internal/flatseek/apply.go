@@ -0,0 +1,50 @@
+package flatseek
+
+import (
+	"fmt"
+
+	"github.com/XnLogicaL/flatseek/internal/args"
+	"github.com/XnLogicaL/flatseek/internal/config"
+)
+
+// Apply converges the machine to the state file of our flags without user interaction.
+// The plan is printed and only carried out if it is not a dry-run.
+// Returns true if the machine differed from the state
+func Apply(conf *config.Settings, flags args.Flags) (bool, error) {
+	installation := flags.Installation
+	if installation == "" {
+		installation = conf.Installation
+	}
+	state, err := loadState(flags.StateFile, installation)
+	if err != nil {
+		return false, err
+	}
+	backend, err := newBackend(conf, flags)
+	if err != nil {
+		return false, err
+	}
+	backend.SetNonInteractive(true)
+
+	diff, err := diffState(backend, state)
+	if err != nil {
+		return false, err
+	}
+
+	// extra apps and masks are only drift if the state asks to prune them
+	if !state.Prune {
+		diff.ExtraApps = nil
+		diff.ExtraMasks = nil
+	}
+	if diff.empty() {
+		fmt.Println("Nothing to do, the machine matches " + flags.StateFile)
+		return false, nil
+	}
+
+	for _, line := range diff.plan() {
+		fmt.Println(line)
+	}
+	if flags.DryRun {
+		return true, nil
+	}
+	return true, applyStateDiff(backend, diff, state.Prune)
+}
@@ -0,0 +1,425 @@
+package flatseek
+
+import (
+	"compress/gzip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/XnLogicaL/flatseek/internal/util"
+)
+
+// appstreamComponent is an app or runtime described in the AppStream data of a remote
+type appstreamComponent struct {
+	Type           string   `xml:"type,attr"`
+	ID             string   `xml:"id"`
+	Names          []l10n   `xml:"name"`
+	Summaries      []l10n   `xml:"summary"`
+	DeveloperNames []l10n   `xml:"developer_name"`
+	Developers     []l10n   `xml:"developer>name"`
+	Keywords       []l10n   `xml:"keywords>keyword"`
+	Categories     []string `xml:"categories>category"`
+	Bundle         string   `xml:"bundle"`
+	License        string   `xml:"project_license"`
+	URLs           []struct {
+		Type  string `xml:"type,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"url"`
+	ContentRating struct {
+		Type       string `xml:"type,attr"`
+		Attributes []struct {
+			ID    string `xml:"id,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"content_attribute"`
+	} `xml:"content_rating"`
+	Releases []struct {
+		Version     string `xml:"version,attr"`
+		Timestamp   int64  `xml:"timestamp,attr"`
+		Date        string `xml:"date,attr"`
+		Description struct {
+			Inner string `xml:",innerxml"`
+		} `xml:"description"`
+	} `xml:"releases>release"`
+
+	// filled in after parsing
+	name         string
+	summary      string
+	developer    string
+	remote       string
+	installation string
+	ref          Ref
+}
+
+// AppDetails is the information about an app from the AppStream data of its remote
+type AppDetails struct {
+	Developer     string
+	License       string
+	Categories    []string
+	Keywords      []string
+	URLs          map[string]string // by AppStream url type: homepage, bugtracker, help, donation, translate...
+	ContentRating string
+	Releases      []Release
+}
+
+// Release is a version of an app with its release notes
+type Release struct {
+	Version     string
+	Date        string
+	Description string
+}
+
+// maxReleases is the number of releases we keep for the details of an app
+const maxReleases = 3
+
+// l10n is a translatable AppStream element
+type l10n struct {
+	Lang  string `xml:"lang,attr"`
+	Value string `xml:",chardata"`
+}
+
+// appstreamFile is a remote's appstream.xml.gz within an installation
+type appstreamFile struct {
+	path         string
+	remote       string
+	installation string
+	modTime      time.Time
+}
+
+// appstreamIndex holds the AppStream components of all remotes for searching.
+// files we could not read are skipped, their errors are kept to report them
+type appstreamIndex struct {
+	locker     *sync.Mutex
+	files      []appstreamFile
+	components []appstreamComponent
+	skipped    error
+}
+
+// newAppstreamIndex creates an empty index
+func newAppstreamIndex() *appstreamIndex {
+	return &appstreamIndex{
+		locker: &sync.Mutex{},
+	}
+}
+
+// returns the untranslated value of a translatable element
+func untranslated(values []l10n) string {
+	for _, v := range values {
+		if v.Lang == "" {
+			return strings.TrimSpace(v.Value)
+		}
+	}
+	if len(values) > 0 {
+		return strings.TrimSpace(values[0].Value)
+	}
+	return ""
+}
+
+// returns the names of the disabled remotes in the repo config of an installation
+func disabledRemotes(installationPath string) []string {
+	data, err := os.ReadFile(path.Join(installationPath, "repo", "config"))
+	if err != nil {
+		return nil
+	}
+	names := []string{}
+	for group, keys := range parseKeyFile(string(data)) {
+		name, found := strings.CutPrefix(group, "remote ")
+		if found && keys["xa.disable"] == "true" {
+			names = append(names, strings.Trim(name, "\""))
+		}
+	}
+	return names
+}
+
+// looks up the appstream files of all enabled remotes in all installations for our architecture.
+// disabled remotes keep their appstream data, but we can't install from them
+func findAppstreamFiles() []appstreamFile {
+	files := []appstreamFile{}
+	for _, inst := range listInstallations() {
+		disabled := disabledRemotes(inst.Path)
+		matches, _ := filepath.Glob(path.Join(inst.Path, "appstream", "*", defaultArch(), "active", "appstream.xml.gz"))
+		for _, match := range matches {
+			fi, err := os.Stat(match)
+			if err != nil {
+				continue
+			}
+			remote := path.Base(path.Dir(path.Dir(path.Dir(match))))
+			if util.IndexOf(disabled, remote) >= 0 {
+				continue
+			}
+			files = append(files, appstreamFile{
+				path:         match,
+				remote:       remote,
+				installation: inst.ID,
+				modTime:      fi.ModTime(),
+			})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].path < files[j].path
+	})
+	return files
+}
+
+// parses an appstream.xml.gz file
+func parseAppstreamFile(file appstreamFile) ([]appstreamComponent, error) {
+	f, err := os.Open(file.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	data := struct {
+		Components []appstreamComponent `xml:"component"`
+	}{}
+	if err = xml.NewDecoder(gz).Decode(&data); err != nil {
+		return nil, err
+	}
+
+	components := make([]appstreamComponent, 0, len(data.Components))
+	for _, c := range data.Components {
+		ref, err := ParseRef(c.Bundle)
+		if err != nil {
+			continue
+		}
+		c.ref = ref
+		c.remote = file.remote
+		c.installation = file.installation
+		c.name = untranslated(c.Names)
+		c.summary = untranslated(c.Summaries)
+		c.developer = untranslated(c.Developers)
+		if c.developer == "" {
+			c.developer = untranslated(c.DeveloperNames)
+		}
+		components = append(components, c)
+	}
+	return components, nil
+}
+
+// (re-)loads the index if the appstream data on disk has changed.
+// returns false if there is no appstream data at all
+func (idx *appstreamIndex) load() bool {
+	files := findAppstreamFiles()
+	if len(files) == 0 {
+		return false
+	}
+
+	changed := len(files) != len(idx.files)
+	for i := 0; !changed && i < len(files); i++ {
+		changed = files[i] != idx.files[i]
+	}
+	if !changed {
+		return true
+	}
+
+	// a broken file of one remote should not keep us from searching the others
+	components := []appstreamComponent{}
+	skipped := []error{}
+	for _, file := range files {
+		c, err := parseAppstreamFile(file)
+		if err != nil {
+			skipped = append(skipped, fmt.Errorf("skipped the AppStream data of %s (%s): %w", file.remote, file.installation, err))
+			continue
+		}
+		components = append(components, c...)
+	}
+	idx.files = files
+	idx.components = components
+	idx.skipped = errors.Join(skipped...)
+	return true
+}
+
+// checks if a value matches the search-term
+func matchesTerm(value, term, mode string) bool {
+	value = strings.ToLower(value)
+	if mode == "StartsWith" {
+		return strings.HasPrefix(value, term)
+	}
+	return strings.Contains(value, term)
+}
+
+// checks if a component matches the search-term with the given search mode / search by settings
+func (c appstreamComponent) matches(term, mode, by string) bool {
+	if matchesTerm(c.name, term, mode) || matchesTerm(c.ref.ID, term, mode) {
+		return true
+	}
+	if by == "Name" {
+		return false
+	}
+
+	fields := []string{c.summary, c.developer}
+	fields = append(fields, c.Categories...)
+	for _, k := range c.Keywords {
+		fields = append(fields, k.Value)
+	}
+	for _, f := range fields {
+		if matchesTerm(f, term, mode) {
+			return true
+		}
+	}
+	return false
+}
+
+// searches the index, optionally limited to an installation.
+// returns false if there is no appstream data available; the error tells which data we skipped
+func (idx *appstreamIndex) search(term, mode, by, installation string) ([]Package, bool, error) {
+	idx.locker.Lock()
+	defer idx.locker.Unlock()
+
+	if !idx.load() {
+		return nil, false, nil
+	}
+
+	term = strings.ToLower(term)
+	packages := []Package{}
+	for _, c := range idx.components {
+		if installation != "" && c.installation != installation {
+			continue
+		}
+		if !c.matches(term, mode, by) {
+			continue
+		}
+		pkg := Package{
+			Ref:          c.ref,
+			Name:         c.name,
+			Description:  c.summary,
+			Remote:       c.remote,
+			Installation: c.installation,
+		}
+		if len(c.Releases) > 0 {
+			pkg.Version = c.Releases[0].Version
+		}
+		packages = append(packages, pkg)
+	}
+	return packages, true, idx.skipped
+}
+
+// converts the markup of an AppStream description (paragraphs and lists) to plain text.
+// translated elements are skipped
+func appstreamText(markup string) string {
+	dec := xml.NewDecoder(strings.NewReader("<d>" + markup + "</d>"))
+	parts := []string{}
+	text := ""
+	translated := []bool{false}
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		skip := translated[len(translated)-1]
+		switch t := tok.(type) {
+		case xml.StartElement:
+			for _, a := range t.Attr {
+				skip = skip || a.Name.Local == "lang"
+			}
+			translated = append(translated, skip)
+			if t.Name.Local == "li" && !skip {
+				text = "- "
+			}
+		case xml.CharData:
+			if !skip {
+				text += string(t)
+			}
+		case xml.EndElement:
+			translated = translated[:len(translated)-1]
+			if (t.Name.Local == "p" || t.Name.Local == "li") && !skip {
+				if strings.TrimSpace(text) != "" {
+					parts = append(parts, strings.Join(strings.Fields(text), " "))
+				}
+				text = ""
+			}
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// summarizes the OARS content rating of a component, e.g. "violence-cartoon (mild), social-chat (intense)"
+func (c appstreamComponent) contentRating() string {
+	if c.ContentRating.Type == "" {
+		return ""
+	}
+	ratings := []string{}
+	for _, a := range c.ContentRating.Attributes {
+		if v := strings.TrimSpace(a.Value); v != "" && v != "none" {
+			ratings = append(ratings, a.ID+" ("+v+")")
+		}
+	}
+	if len(ratings) == 0 {
+		return "Suitable for all ages"
+	}
+	return strings.Join(ratings, ", ")
+}
+
+// returns the details of a component
+func (c appstreamComponent) details() AppDetails {
+	d := AppDetails{
+		Developer:     c.developer,
+		License:       strings.TrimSpace(c.License),
+		Categories:    c.Categories,
+		URLs:          map[string]string{},
+		ContentRating: c.contentRating(),
+	}
+	for _, k := range c.Keywords {
+		if k.Lang == "" {
+			d.Keywords = append(d.Keywords, strings.TrimSpace(k.Value))
+		}
+	}
+	for _, u := range c.URLs {
+		if _, ok := d.URLs[u.Type]; !ok && u.Type != "" {
+			d.URLs[u.Type] = strings.TrimSpace(u.Value)
+		}
+	}
+	for i, rel := range c.Releases {
+		if i == maxReleases {
+			break
+		}
+		date := rel.Date
+		if rel.Timestamp > 0 {
+			date = time.Unix(rel.Timestamp, 0).UTC().Format("2006-01-02")
+		}
+		d.Releases = append(d.Releases, Release{
+			Version:     rel.Version,
+			Date:        date,
+			Description: appstreamText(rel.Description.Inner),
+		})
+	}
+	return d
+}
+
+// returns the details of a package from the appstream data of its remote.
+// returns false if there is no appstream data for it
+func (idx *appstreamIndex) details(pkg Package) (AppDetails, bool, error) {
+	idx.locker.Lock()
+	defer idx.locker.Unlock()
+
+	if !idx.load() {
+		return AppDetails{}, false, nil
+	}
+
+	// prefer the component of the package's remote and installation
+	var found *appstreamComponent
+	for i, c := range idx.components {
+		if c.ref.ID != pkg.ID {
+			continue
+		}
+		if found == nil || (c.remote == pkg.Remote && c.installation == pkg.Installation) {
+			found = &idx.components[i]
+		}
+	}
+	if found == nil {
+		return AppDetails{}, false, nil
+	}
+	return found.details(), true, nil
+}
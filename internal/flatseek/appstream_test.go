@@ -0,0 +1,75 @@
+package flatseek
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path"
+	"slices"
+	"testing"
+)
+
+// writes the appstream data of a remote to an installation
+func writeAppstream(t *testing.T, installation, remote string, data []byte) {
+	t.Helper()
+	dir := path.Join(installation, "appstream", remote, defaultArch(), "active")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path.Join(dir, "appstream.xml.gz"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// compresses an appstream file with one app
+func appstreamXML(t *testing.T, id string) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	gz := gzip.NewWriter(&b)
+	gz.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<components version="0.8" origin="flatpak">
+  <component type="desktop-application">
+    <id>` + id + `</id>
+    <name>` + id + `</name>
+    <summary>Test app</summary>
+    <bundle type="flatpak">app/` + id + `/` + defaultArch() + `/stable</bundle>
+  </component>
+</components>`))
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func TestAppstreamIndexSearch(t *testing.T) {
+	system, user := t.TempDir(), t.TempDir()
+	t.Setenv("FLATPAK_SYSTEM_DIR", system)
+	t.Setenv("FLATPAK_USER_DIR", user)
+	t.Setenv("FLATPAK_CONFIG_DIR", t.TempDir())
+
+	writeAppstream(t, system, "flathub", appstreamXML(t, "org.example.Enabled"))
+	writeAppstream(t, system, "broken", []byte("not gzip"))
+	writeAppstream(t, user, "flathub-beta", appstreamXML(t, "org.example.Disabled"))
+	if err := os.MkdirAll(path.Join(user, "repo"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	config := "[core]\nmode=bare-user-only\n\n[remote \"flathub-beta\"]\nurl=https://dl.flathub.org/beta-repo/\nxa.disable=true\n"
+	if err := os.WriteFile(path.Join(user, "repo", "config"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	packages, available, err := newAppstreamIndex().search("org.example", "Contains", "Name", "")
+	if !available {
+		t.Fatal("no appstream data found")
+	}
+	if err == nil {
+		t.Error("the broken appstream data was not reported")
+	}
+	got := []string{}
+	for _, pkg := range packages {
+		got = append(got, pkg.ID+" "+pkg.Remote+" "+pkg.Installation)
+	}
+	if want := []string{"org.example.Enabled flathub system"}; !slices.Equal(got, want) {
+		t.Errorf("search() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,134 @@
+package flatseek
+
+import (
+	"time"
+
+	"github.com/XnLogicaL/flatseek/internal/config"
+)
+
+// Backend is the interface to the flatpak ecosystem our UI depends on
+type Backend interface {
+	// SetInstallation limits all operations to an installation, all installations are used if it is empty
+	SetInstallation(installation string)
+	// SetNonInteractive makes install and uninstall commands proceed without asking questions
+	SetNonInteractive(nonInteractive bool)
+	// Search returns all packages available on the remotes matching a search-term;
+	// they may come along with an error about remotes which could not be searched
+	Search(term string) ([]Package, error)
+	// Info returns detailed information for a package
+	Info(pkg Package) (Package, error)
+	// Metadata returns the metadata file of a package
+	Metadata(pkg Package) (string, error)
+	// Manifest returns the manifest an installed app was built with, if it ships one
+	Manifest(pkg Package) (string, error)
+	// Details returns the AppStream information of a package like its license, URLs and releases
+	Details(pkg Package) (AppDetails, error)
+	// Overrides returns the permission overrides of an installed app in its installation
+	Overrides(pkg Package) (Overrides, error)
+	// SetOverrides replaces the permission overrides of an installed app; empty overrides reset them
+	SetOverrides(pkg Package, overrides Overrides) error
+	// ListInstalled returns all installed packages
+	ListInstalled() ([]Package, error)
+	// ListUpdates returns all installed packages for which an update is available
+	ListUpdates() ([]Package, error)
+	// Install installs a package
+	Install(pkg Package) error
+	// Uninstall removes a package
+	Uninstall(pkg Package) error
+	// UninstallAll removes the given packages in one transaction per installation
+	UninstallAll(pkgs []Package) error
+	// LastUsed returns when an installed package was used last, the zero time if that is unknown
+	LastUsed(pkg Package) (time.Time, error)
+	// InstallBundle installs the ref of a .flatpak or .flatpakref file into an installation
+	InstallBundle(bundle Bundle, installation string) error
+	// Update updates the given packages to their latest commit
+	Update(pkgs []Package) error
+	// Run launches an installed app with the options of a launch profile
+	Run(pkg Package, profile config.LaunchProfile) error
+	// History returns the commit log of a package on its remote, latest commit first
+	History(pkg Package) ([]Commit, error)
+	// Deploy updates or downgrades an installed package to a specific commit
+	Deploy(pkg Package, commit string) error
+	// Remotes returns all configured remotes
+	Remotes() ([]Remote, error)
+	// AddRemote adds a remote from a repository URL or .flatpakrepo file
+	AddRemote(name, location, installation string) error
+	// RemoveRemote removes a remote; force is required if refs are still installed from it
+	RemoveRemote(remote Remote, force bool) error
+	// SetRemoteEnabled enables or disables a remote
+	SetRemoteEnabled(remote Remote, enabled bool) error
+	// SetRemoteURL changes the repository URL of a remote
+	SetRemoteURL(remote Remote, url string) error
+	// UpdateAppstream downloads the latest AppStream data of a remote
+	UpdateAppstream(remote Remote) error
+	// Instances returns the running instances of apps
+	Instances() ([]Instance, error)
+	// Kill stops a running instance
+	Kill(instance Instance) error
+	// Masks returns all masks and pins
+	Masks() ([]Mask, error)
+	// AddMask masks or pins refs matching a pattern
+	AddMask(mask Mask) error
+	// RemoveMask removes a mask or pin
+	RemoveMask(mask Mask) error
+}
+
+// Remote is a flatpak repository packages can be installed from
+type Remote struct {
+	Name             string
+	Title            string
+	URL              string
+	Priority         int
+	Filter           string
+	Installation     string
+	Disabled         bool
+	AppstreamUpdated time.Time
+}
+
+// Instance is a running sandbox of an app; CPU and RSS are filled in from /proc by our UI
+type Instance struct {
+	ID       string
+	PID      int
+	ChildPID int
+	App      string
+	Arch     string
+	Branch   string
+	Runtime  string
+	CPU      float64
+	RSS      uint64
+}
+
+// Mask is a pattern of refs excluded from updates, or a pin keeping runtimes from being removed automatically
+type Mask struct {
+	Pattern      string
+	Installation string
+	Pin          bool
+}
+
+// searches our backend for packages and returns them along with installed ones matching the term
+func (ps *UI) pkgSearch(term string) ([]Package, []Package, error) {
+	if err := ps.loadInstalledRefs(); err != nil {
+		return nil, nil, err
+	}
+
+	// we may get results along with an error about remotes which could not be searched
+	packages, err := ps.backend.Search(term)
+	if packages == nil {
+		return nil, nil, err
+	}
+	for i := range packages {
+		if ipkg, found := ps.getInstalledRef(packages[i]); found {
+			packages[i].IsInstalled = true
+			if packages[i].Installation == "" {
+				packages[i].Installation = ipkg.Installation
+			}
+		}
+	}
+	installed := ps.searchInstalledRefs(term)
+
+	return packages, installed, err
+}
+
+func (ps *UI) pkgGetSuggestion(text string) string {
+	return "dihh"
+}
@@ -0,0 +1,337 @@
+package flatseek
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const bundleHeaderSize = 4 * 1024 * 1024 // the metadata of a bundle is at the beginning of the file
+
+// Bundle is a local file apps or remotes can be installed from:
+// a single-file bundle (.flatpak), a ref description (.flatpakref) or a repository description (.flatpakrepo)
+type Bundle struct {
+	File        string
+	Kind        string // "bundle", "flatpakref" or "flatpakrepo"
+	Ref         Ref
+	Title       string
+	Runtime     string
+	RemoteName  string
+	RemoteURL   string
+	RuntimeRepo string
+	HasGPGKey   bool
+}
+
+// checks if a file name looks like something we can install from
+func isBundleFile(file string) bool {
+	switch path.Ext(file) {
+	case ".flatpak", ".flatpakref", ".flatpakrepo":
+		return true
+	}
+	return false
+}
+
+// reads the information of a bundle, flatpakref or flatpakrepo file
+func loadBundle(file string) (Bundle, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return Bundle{}, err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, bundleHeaderSize))
+	if err != nil {
+		return Bundle{}, err
+	}
+
+	switch path.Ext(file) {
+	case ".flatpakref":
+		return parseFlatpakRef(file, string(data))
+	case ".flatpakrepo":
+		return parseFlatpakRepo(file, string(data))
+	case ".flatpak":
+		return parseBundleHeader(file, data)
+	}
+	return Bundle{}, fmt.Errorf("%s: not a .flatpak, .flatpakref or .flatpakrepo file", file)
+}
+
+// parses the [Flatpak Ref] group of a .flatpakref file
+func parseFlatpakRef(file, data string) (Bundle, error) {
+	group := parseKeyFile(data)["Flatpak Ref"]
+	if group["Name"] == "" || group["Url"] == "" {
+		return Bundle{}, fmt.Errorf("%s: Name or Url missing in [Flatpak Ref]", file)
+	}
+	b := Bundle{
+		File:        file,
+		Kind:        "flatpakref",
+		Ref:         Ref{Kind: "app", ID: group["Name"], Branch: group["Branch"]},
+		Title:       group["Title"],
+		RemoteName:  group["SuggestRemoteName"],
+		RemoteURL:   group["Url"],
+		RuntimeRepo: group["RuntimeRepo"],
+		HasGPGKey:   group["GPGKey"] != "",
+	}
+	if group["IsRuntime"] == "true" {
+		b.Ref.Kind = "runtime"
+	}
+	if b.Ref.Branch == "" {
+		b.Ref.Branch = "master"
+	}
+	// flatpak names the remote after the app unless a name is suggested
+	if b.RemoteName == "" {
+		b.RemoteName = b.Ref.ID + "-origin"
+	}
+	return b, nil
+}
+
+// parses the [Flatpak Repo] group of a .flatpakrepo file; the remote is named after the file
+func parseFlatpakRepo(file, data string) (Bundle, error) {
+	group := parseKeyFile(data)["Flatpak Repo"]
+	if group["Url"] == "" {
+		return Bundle{}, fmt.Errorf("%s: Url missing in [Flatpak Repo]", file)
+	}
+	return Bundle{
+		File:       file,
+		Kind:       "flatpakrepo",
+		Title:      group["Title"],
+		RemoteName: strings.TrimSuffix(path.Base(file), ".flatpakrepo"),
+		RemoteURL:  group["Url"],
+		HasGPGKey:  group["GPGKey"] != "",
+	}, nil
+}
+
+// extracts the ref, origin and metadata of a single-file bundle.
+// they are stored in a GVariant dictionary at the beginning of the file
+func parseBundleHeader(file string, data []byte) (Bundle, error) {
+	ref, err := ParseRef(gvariantString(data, "ref"))
+	if err != nil {
+		return Bundle{}, fmt.Errorf("%s: not a flatpak bundle", file)
+	}
+	b := Bundle{
+		File:        file,
+		Kind:        "bundle",
+		Ref:         ref,
+		RemoteURL:   gvariantString(data, "origin"),
+		RuntimeRepo: gvariantString(data, "runtime-repo"),
+		HasGPGKey:   bytes.Contains(data, []byte("gpg-keys\x00")),
+	}
+	metadata := parseKeyFile(gvariantString(data, "metadata"))
+	b.Title = metadata["Application"]["name"]
+	b.Runtime = metadata["Application"]["runtime"]
+	if b.RemoteURL != "" {
+		b.RemoteName = ref.ID + "-origin"
+	}
+	return b, nil
+}
+
+// returns the string value of a key in a serialized GVariant dictionary (a{sv}).
+// an entry is the key, padding, the value and the variant's type signature "s", all NUL separated
+func gvariantString(data []byte, key string) string {
+	needle := []byte(key + "\x00")
+	for i := bytes.Index(data, needle); i >= 0; {
+		rest := bytes.TrimLeft(data[i+len(needle):], "\x00")
+		if end := bytes.IndexByte(rest, 0); end > 0 && bytes.HasPrefix(rest[end:], []byte("\x00\x00s")) {
+			return string(rest[:end])
+		}
+		next := bytes.Index(data[i+1:], needle)
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+	return ""
+}
+
+// returns the remotes which have the name of our bundle's remote but a different URL
+func (b Bundle) conflictingRemotes(remotes []Remote) []Remote {
+	conflicts := []Remote{}
+	for _, r := range remotes {
+		if r.Name == b.RemoteName && b.RemoteURL != "" &&
+			strings.TrimSuffix(r.URL, "/") != strings.TrimSuffix(b.RemoteURL, "/") {
+			conflicts = append(conflicts, r)
+		}
+	}
+	return conflicts
+}
+
+// returns the files and directories in the directory of a path which can be opened
+func bundleCompletions(text string) []string {
+	if text == "" {
+		return nil
+	}
+	dir, prefix := path.Split(text)
+	if dir == "" {
+		dir = "."
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	completions := []string{}
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, prefix) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(prefix, ".")) {
+			continue
+		}
+		if e.IsDir() {
+			completions = append(completions, path.Join(dir, name)+"/")
+		} else if isBundleFile(name) {
+			completions = append(completions, path.Join(dir, name))
+		}
+	}
+	sort.Strings(completions)
+	return completions
+}
+
+// displays a form to pick a bundle, flatpakref or flatpakrepo file
+func (ps *UI) displayOpenBundle() {
+	cwd, _ := os.Getwd()
+	form := tview.NewForm().
+		AddInputField("File: ", cwd+"/", 60, nil, nil)
+	input := form.GetFormItemByLabel("File: ").(*tview.InputField)
+	input.SetAutocompleteFunc(bundleCompletions).
+		SetAutocompleteStyles(ps.conf.Colors().SettingsDropdownNotSelected, tcell.StyleDefault, tcell.StyleDefault.Reverse(true))
+
+	form.AddButton("Open", func() {
+		file := strings.TrimSpace(input.GetText())
+		ps.closeDialogForm()
+		ps.displayBundle(file)
+	}).
+		AddButton("Cancel", func() {
+			ps.closeDialogForm()
+		})
+	form.SetTitle(" [::b]" + ps.conf.Glyphs().Package + "Open .flatpak / .flatpakref / .flatpakrepo file ")
+
+	ps.showDialogForm(form)
+}
+
+// reads a bundle and previews what would be installed from it
+func (ps *UI) displayBundle(file string) {
+	ps.tableDetails.Clear().
+		SetTitle(" [::b]Reading " + path.Base(file) + "... ")
+
+	go func() {
+		ps.locker.Lock()
+		ps.startSpinner()
+		defer func() {
+			ps.locker.Unlock()
+			ps.stopSpinner()
+		}()
+
+		b, err := loadBundle(file)
+		var remotes []Remote
+		if err == nil {
+			remotes, err = ps.backend.Remotes()
+		}
+		ps.app.QueueUpdateDraw(func() {
+			if err != nil {
+				ps.tableDetails.SetTitle(" [::b]Error ")
+				ps.displayMessage(err.Error(), true)
+				return
+			}
+			ps.drawBundle(b, b.conflictingRemotes(remotes))
+		})
+	}()
+}
+
+// draws the preview of a bundle with buttons to install it into an installation
+func (ps *UI) drawBundle(b Bundle, conflicts []Remote) {
+	ps.tableDetails.Clear().
+		SetTitle(" [::b]" + ps.conf.Glyphs().Package + path.Base(b.File) + " ")
+
+	// remove "Latest news" if they were shown previously
+	if ps.flexRight.GetItemCount() == 2 {
+		ps.flexRight.RemoveItem(ps.flexRight.GetItem(1))
+	}
+
+	none := func(s string) string {
+		if s == "" {
+			return "-"
+		}
+		return s
+	}
+	gpg := "[red]no, the remote will not be verified"
+	if b.HasGPGKey {
+		gpg = "yes"
+	}
+	fields := [][]string{{"Title", none(b.Title)}}
+	if b.Kind != "flatpakrepo" {
+		fields = append(fields,
+			[]string{"App id", b.Ref.ID},
+			[]string{"Kind", b.Ref.Kind},
+			[]string{"Branch", b.Ref.Branch},
+			[]string{"Runtime", none(b.Runtime)},
+			[]string{"Runtime repo", none(b.RuntimeRepo)})
+	}
+	if b.RemoteURL != "" {
+		fields = append(fields,
+			[]string{"Adds remote", b.RemoteName},
+			[]string{"Remote URL", b.RemoteURL},
+			[]string{"GPG key", gpg})
+	}
+
+	r := 0
+	for _, f := range fields {
+		ps.tableDetails.SetCell(r, 0, &tview.TableCell{
+			Text:            "[::b]" + f[0],
+			Color:           ps.conf.Colors().PackagelistHeader,
+			BackgroundColor: ps.conf.Colors().DefaultBackground,
+		})
+		ps.tableDetails.SetCell(r, 1, &tview.TableCell{
+			Text:            f[1],
+			Color:           tcell.ColorWhite,
+			BackgroundColor: ps.conf.Colors().DefaultBackground,
+		})
+		r++
+	}
+
+	// warn about remotes which would be shadowed
+	for _, c := range conflicts {
+		r++
+		ps.tableDetails.SetCell(r, 0, &tview.TableCell{
+			Text:            "[::b]Warning",
+			Color:           ps.conf.Colors().PackagelistHeader,
+			BackgroundColor: ps.conf.Colors().DefaultBackground,
+		})
+		ps.tableDetails.SetCell(r, 1, &tview.TableCell{
+			Text:            fmt.Sprintf("[red]Remote %s (%s) exists with a different URL: %s", c.Name, c.Installation, c.URL),
+			Color:           tcell.ColorWhite,
+			BackgroundColor: ps.conf.Colors().DefaultBackground,
+		})
+	}
+
+	// install buttons, one per installation
+	installations := installationIDs()
+	if ps.installation != "" {
+		installations = []string{ps.installation}
+	}
+	action := "Install into "
+	if b.Kind == "flatpakrepo" {
+		action = "Add remote to "
+	}
+	r += 2
+	for _, inst := range installations {
+		inst := inst
+		ps.tableDetails.SetCell(r, 0, &tview.TableCell{
+			Text:            " [::b]" + action + inst,
+			Align:           tview.AlignCenter,
+			Color:           ps.conf.Colors().SettingsFieldText,
+			BackgroundColor: ps.conf.Colors().SearchBar,
+			Clicked: func() bool {
+				ps.installBundle(b, inst)
+				return true
+			},
+		})
+		r += 2
+	}
+
+	// set nil to avoid printing package details when resizing
+	ps.selectedPackage = nil
+	ps.tableDetails.ScrollToBeginning()
+}
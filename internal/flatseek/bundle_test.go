@@ -0,0 +1,107 @@
+package flatseek
+
+import "testing"
+
+func TestGvariantString(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		key  string
+		want string
+	}{
+		{
+			name: "value",
+			data: "\x00\x00ref\x00\x00\x00app/org.x.Y/x86_64/stable\x00\x00s\x00origin\x00https://example.org/repo/\x00\x00s",
+			key:  "ref",
+			want: "app/org.x.Y/x86_64/stable",
+		},
+		{
+			name: "last value",
+			data: "ref\x00app/org.x.Y/x86_64/stable\x00\x00s\x00origin\x00\x00https://example.org/repo/\x00\x00s",
+			key:  "origin",
+			want: "https://example.org/repo/",
+		},
+		{
+			name: "key also in another value",
+			data: "metadata\x00[Application]\nruntime-ref\x00x\x00\x00ref\x00app/org.x.Y/x86_64/stable\x00\x00s",
+			key:  "ref",
+			want: "app/org.x.Y/x86_64/stable",
+		},
+		{
+			name: "not a string",
+			data: "ref\x00app/org.x.Y/x86_64/stable\x00\x00t",
+			key:  "ref",
+			want: "",
+		},
+		{
+			name: "missing",
+			data: "origin\x00https://example.org/repo/\x00\x00s",
+			key:  "ref",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := gvariantString([]byte(tt.data), tt.key); got != tt.want {
+				t.Errorf("gvariantString(%q) = %q, want %q", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFlatpakRef(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    Bundle
+		wantErr bool
+	}{
+		{
+			name: "app",
+			data: "[Flatpak Ref]\nTitle=GIMP\nName=org.gimp.GIMP\nBranch=stable\nUrl=https://dl.flathub.org/repo/\n" +
+				"SuggestRemoteName=flathub\nIsRuntime=false\nGPGKey=abc\nRuntimeRepo=https://dl.flathub.org/repo/flathub.flatpakrepo\n",
+			want: Bundle{
+				File:        "x.flatpakref",
+				Kind:        "flatpakref",
+				Ref:         Ref{Kind: "app", ID: "org.gimp.GIMP", Branch: "stable"},
+				Title:       "GIMP",
+				RemoteName:  "flathub",
+				RemoteURL:   "https://dl.flathub.org/repo/",
+				RuntimeRepo: "https://dl.flathub.org/repo/flathub.flatpakrepo",
+				HasGPGKey:   true,
+			},
+		},
+		{
+			name: "runtime without branch and remote name",
+			data: "[Flatpak Ref]\nName=org.x.Platform\nUrl=https://example.org/repo/\nIsRuntime=true\n",
+			want: Bundle{
+				File:       "x.flatpakref",
+				Kind:       "flatpakref",
+				Ref:        Ref{Kind: "runtime", ID: "org.x.Platform", Branch: "master"},
+				RemoteName: "org.x.Platform-origin",
+				RemoteURL:  "https://example.org/repo/",
+			},
+		},
+		{
+			name:    "without url",
+			data:    "[Flatpak Ref]\nName=org.gimp.GIMP\n",
+			wantErr: true,
+		},
+		{
+			name:    "other group",
+			data:    "[Flatpak Repo]\nName=org.gimp.GIMP\nUrl=https://dl.flathub.org/repo/\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFlatpakRef("x.flatpakref", tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseFlatpakRef() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
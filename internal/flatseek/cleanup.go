@@ -0,0 +1,281 @@
+package flatseek
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// unusedRef is an installed runtime or extension no installed app depends on
+type unusedRef struct {
+	Package
+	LastUsed  time.Time
+	Pinned    bool
+	Extension bool
+}
+
+// dependency is an installed package another one pulls in: as its runtime, sdk or as an extension
+type dependency struct {
+	From Package
+	To   Package
+	As   string // "runtime", "sdk" or "extension"
+}
+
+// returns the dependencies between installed packages, read from their metadata
+func installedDependencies(b Backend, installed []Package) ([]dependency, error) {
+	deps := []dependency{}
+	for _, pkg := range installed {
+		metadata, err := b.Metadata(pkg)
+		if err != nil {
+			return nil, err
+		}
+		kf := parseKeyFile(metadata)
+		group := "Application"
+		if pkg.Kind == "runtime" {
+			group = "Runtime"
+		}
+
+		for _, key := range []string{"runtime", "sdk"} {
+			ref, err := ParseRef(kf[group][key])
+			if err != nil {
+				continue
+			}
+			for _, p := range installed {
+				// runtimes name themselves as runtime
+				if p.Ref.matches(ref.withKind("runtime")) && p.key() != pkg.key() {
+					deps = append(deps, dependency{From: pkg, To: p, As: key})
+				}
+			}
+		}
+		for _, point := range extensionPoints(kf) {
+			for _, p := range installedExtensions(installed, point) {
+				if p.key() != pkg.key() {
+					deps = append(deps, dependency{From: pkg, To: p, As: "extension"})
+				}
+			}
+		}
+	}
+	return deps, nil
+}
+
+// returns the installed runtimes and extensions no installed app depends on.
+// the runtimes of apps are in use, as are the extensions of anything in use; sdks are only needed to build apps
+func unusedRuntimes(b Backend, installed []Package) ([]Package, error) {
+	deps, err := installedDependencies(b, installed)
+	if err != nil {
+		return nil, err
+	}
+
+	used := map[string]bool{}
+	queue := []Package{}
+	for _, pkg := range installed {
+		if pkg.Kind == "app" {
+			used[pkg.key()] = true
+			queue = append(queue, pkg)
+		}
+	}
+	for len(queue) > 0 {
+		pkg := queue[0]
+		queue = queue[1:]
+		for _, d := range deps {
+			if d.As == "sdk" || d.From.key() != pkg.key() || used[d.To.key()] {
+				continue
+			}
+			used[d.To.key()] = true
+			queue = append(queue, d.To)
+		}
+	}
+
+	unused := []Package{}
+	for _, pkg := range installed {
+		if !used[pkg.key()] {
+			unused = append(unused, pkg)
+		}
+	}
+	sort.Slice(unused, func(i, j int) bool {
+		return unused[i].Ref.String() < unused[j].Ref.String()
+	})
+	return unused, nil
+}
+
+// looks for unused runtimes and extensions and displays them for removal
+func (ps *UI) displayCleanup() {
+	ps.tableDetails.Clear().
+		SetTitle(" [::b]Looking for unused runtimes... ")
+
+	go func() {
+		ps.locker.Lock()
+		ps.startSpinner()
+		defer func() {
+			ps.locker.Unlock()
+			ps.stopSpinner()
+		}()
+
+		installed, err := ps.backend.ListInstalled()
+		var masks []Mask
+		if err == nil {
+			masks, err = ps.backend.Masks()
+		}
+		var unused []Package
+		if err == nil {
+			unused, err = unusedRuntimes(ps.backend, installed)
+		}
+
+		// end of life, last use and if it is an extension are only looked up for the few we found
+		refs := []unusedRef{}
+		for _, pkg := range unused {
+			if info, err := ps.backend.Info(pkg); err == nil {
+				pkg.EOL = info.EOL
+			}
+			lastUsed, _ := ps.backend.LastUsed(pkg)
+			metadata, mErr := ps.backend.Metadata(pkg)
+			refs = append(refs, unusedRef{
+				Package:   pkg,
+				LastUsed:  lastUsed,
+				Pinned:    isPinned(pkg, masks),
+				Extension: mErr != nil || isExtension(metadata),
+			})
+		}
+
+		ps.app.QueueUpdateDraw(func() {
+			if err != nil {
+				ps.tableDetails.SetTitle(" [::b]Error ")
+				ps.displayMessage(err.Error(), true)
+				return
+			}
+			// pinned ones and extensions flatpak might still load are kept unless selected
+			selected := map[string]bool{}
+			for _, u := range refs {
+				selected[u.key()] = !u.Pinned && !u.Extension
+			}
+			ps.drawCleanup(refs, selected)
+		})
+	}()
+}
+
+// draws the unused runtimes and extensions which can be (de)selected, with a button to remove the selected ones
+func (ps *UI) drawCleanup(refs []unusedRef, selected map[string]bool) {
+	ps.tableDetails.Clear().
+		SetTitle(" [::b]" + ps.conf.Glyphs().Package + "Unused runtimes and extensions ")
+
+	// remove "Latest news" if they were shown previously
+	if ps.flexRight.GetItemCount() == 2 {
+		ps.flexRight.RemoveItem(ps.flexRight.GetItem(1))
+	}
+
+	// header
+	columns := []string{"Runtime / extension  ", "Installation  ", "Size  ", "Last used  ", ""}
+	for i, col := range columns {
+		ps.tableDetails.SetCell(0, i, &tview.TableCell{
+			Text:            col,
+			Color:           ps.conf.Colors().PackagelistHeader,
+			BackgroundColor: ps.conf.Colors().DefaultBackground,
+		})
+	}
+
+	// lines, clicking them toggles their selection
+	r := 1
+	remove := []Package{}
+	size := 0.0
+	for _, u := range refs {
+		u := u
+		r++
+		check := "[ ]"
+		if selected[u.key()] {
+			check = "[x]"
+			remove = append(remove, u.Package)
+			size += parseSize(u.InstalledSize)
+		}
+		lastUsed := "unknown"
+		if !u.LastUsed.IsZero() {
+			lastUsed = formatAge(u.LastUsed)
+		}
+		note := ""
+		if u.Pinned {
+			note = "[::b]pinned"
+		}
+		ps.tableDetails.SetCell(r, 0, &tview.TableCell{
+			Text:            "[::b]" + tview.Escape(check) + " " + u.Ref.String(),
+			Color:           ps.conf.Colors().Accent,
+			BackgroundColor: ps.conf.Colors().DefaultBackground,
+			Clicked: func() bool {
+				selected[u.key()] = !selected[u.key()]
+				ps.drawCleanup(refs, selected)
+				return true
+			},
+		}).
+			SetCell(r, 1, &tview.TableCell{
+				Text:            u.Installation,
+				Color:           tcell.ColorWhite,
+				BackgroundColor: ps.conf.Colors().DefaultBackground,
+			}).
+			SetCell(r, 2, &tview.TableCell{
+				Text:            u.InstalledSize,
+				Color:           tcell.ColorWhite,
+				BackgroundColor: ps.conf.Colors().DefaultBackground,
+			}).
+			SetCell(r, 3, &tview.TableCell{
+				Text:            lastUsed,
+				Color:           tcell.ColorWhite,
+				BackgroundColor: ps.conf.Colors().DefaultBackground,
+			}).
+			SetCell(r, 4, &tview.TableCell{
+				Text:            note,
+				Color:           ps.conf.Colors().PackagelistHeader,
+				BackgroundColor: ps.conf.Colors().DefaultBackground,
+			})
+		if u.Extension {
+			r++
+			ps.tableDetails.SetCell(r, 0, &tview.TableCell{
+				Text:            "  note",
+				Color:           ps.conf.Colors().PackagelistHeader,
+				BackgroundColor: ps.conf.Colors().DefaultBackground,
+			})
+			ps.tableDetails.SetCell(r, 1, &tview.TableCell{
+				Text:            unreferencedExtensionNote,
+				Color:           tcell.ColorWhite,
+				BackgroundColor: ps.conf.Colors().DefaultBackground,
+			})
+		}
+		if u.EOL != "" {
+			r++
+			ps.tableDetails.SetCell(r, 0, &tview.TableCell{
+				Text:            "  eol",
+				Color:           ps.conf.Colors().PackagelistHeader,
+				BackgroundColor: ps.conf.Colors().DefaultBackground,
+			})
+			ps.tableDetails.SetCell(r, 1, &tview.TableCell{
+				Text:            "[red]" + tview.Escape(u.EOL),
+				Color:           tcell.ColorWhite,
+				BackgroundColor: ps.conf.Colors().DefaultBackground,
+			})
+		}
+	}
+
+	// nothing found message else remove button
+	r += 2
+	if len(refs) == 0 {
+		ps.tableDetails.SetCell(r, 0, &tview.TableCell{
+			Text:            "No unused runtimes or extensions found",
+			Color:           ps.conf.Colors().PackagelistHeader,
+			BackgroundColor: ps.conf.Colors().DefaultBackground,
+		})
+	} else if len(remove) > 0 {
+		ps.tableDetails.SetCell(r, 0, &tview.TableCell{
+			Text:            fmt.Sprintf(" [::b]Remove %d selected (%s)", len(remove), formatSize(size)),
+			Align:           tview.AlignCenter,
+			Color:           ps.conf.Colors().SettingsFieldText,
+			BackgroundColor: ps.conf.Colors().SearchBar,
+			Clicked: func() bool {
+				ps.removeUnused(remove)
+				return true
+			},
+		})
+	}
+
+	// set nil to avoid printing package details when resizing
+	ps.selectedPackage = nil
+}
@@ -0,0 +1,61 @@
+package flatseek
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestUnusedRuntimes(t *testing.T) {
+	tests := []struct {
+		name    string
+		without string // a ref we pretend is not installed
+		want    []string
+	}{
+		{
+			name: "installed",
+			want: []string{
+				"runtime/org.freedesktop.Platform.GL.default/x86_64/23.08",
+				"runtime/org.gnome.Platform/x86_64/44",
+				"runtime/org.gnome.Platform/x86_64/45",
+			},
+		},
+		{
+			name:    "without apps",
+			without: "app/org.gnome.Calculator/x86_64/stable",
+			want: []string{
+				"runtime/org.freedesktop.Platform.GL.default/x86_64/23.08",
+				"runtime/org.gnome.Platform.Locale/x86_64/46",
+				"runtime/org.gnome.Platform/x86_64/44",
+				"runtime/org.gnome.Platform/x86_64/45",
+				"runtime/org.gnome.Platform/x86_64/46",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTestBackend(t)
+			all, err := f.ListInstalled()
+			if err != nil {
+				t.Fatal(err)
+			}
+			installed := []Package{}
+			for _, pkg := range all {
+				if pkg.Ref.String() != tt.without {
+					installed = append(installed, pkg)
+				}
+			}
+
+			unused, err := unusedRuntimes(f, installed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := []string{}
+			for _, pkg := range unused {
+				got = append(got, pkg.Ref.String())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("unusedRuntimes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -5,62 +5,177 @@ import (
 	"os"
 	"os/exec"
 	"os/signal"
+	"strings"
+
+	"github.com/rivo/tview"
 )
 
 // installs or removes a package
 func (ps *UI) installPackage(pkg Package, installed bool) {
-	exec.Command("echo", "install works!!!1!")
-	exec.Command("sleep", "10")
+	ps.runTransaction(func() error {
+		if installed {
+			return ps.backend.Uninstall(pkg)
+		}
+		return ps.backend.Install(pkg)
+	})
+	ps.refreshInstalledState()
 }
 
-// installs or removes a package
+// installs or removes the selected package depending on its installed state
 func (ps *UI) installSelectedPackage() {
 	if ps.selectedPackage == nil {
 		return
 	}
-	row, _ := ps.tablePackages.GetSelection()
-	installed := ps.tablePackages.GetCell(row, 2).Reference == true
+	installed := ps.pkgCheckInstalled(*ps.selectedPackage)
 
 	ps.installPackage(*ps.selectedPackage, installed)
 }
 
-// issues "Update command"
+// issues the AUR upgrade command if one is configured, otherwise pending updates are applied
+// through our permission check
 func (ps *UI) performUpgrade(aur bool) {
-	command := ps.conf.SysUpgradeCommand
-	if aur && ps.conf.AurUseDifferentCommands && ps.conf.AurUpgradeCommand != "" {
-		command = ps.conf.AurUpgradeCommand
+	if !aur || !ps.conf.AurUseDifferentCommands || ps.conf.AurUpgradeCommand == "" {
+		ps.upgradeAll()
+		return
 	}
+	command := strings.ReplaceAll(ps.conf.AurUpgradeCommand, "{installation}", strings.Join(installationFlag(ps.installation), " "))
 
 	args := []string{"-c", command}
 
 	ps.runCommand(ps.shell, args...)
+	ps.refreshInstalledState()
+}
+
+// updates the given packages
+func (ps *UI) updatePackages(pkgs ...Package) {
+	ps.runTransaction(func() error {
+		return ps.backend.Update(pkgs)
+	})
+	ps.refreshInstalledState()
+}
+
+// updates all pending packages; updates which widen an app's sandbox, or for which we could not
+// compare the permissions, need to be confirmed
+func (ps *UI) sysupgrade(pending []Package) {
+	widening := []string{}
+	others := []Package{}
+	for _, pkg := range pending {
+		switch {
+		case pkg.GrantsUnknown:
+			widening = append(widening, pkg.ID+": permissions unknown")
+		case len(pkg.NewGrants) > 0:
+			widening = append(widening, pkg.ID+": "+strings.Join(pkg.NewGrants, ", "))
+		default:
+			others = append(others, pkg)
+		}
+	}
+	if len(widening) == 0 {
+		ps.updatePackages(pending...)
+		ps.displayUpgradable()
+		return
+	}
+
+	ask := tview.NewModal().
+		AddButtons([]string{"Update all", "Skip these", "Cancel"}).
+		SetText("These updates may add permissions:\n\n" + strings.Join(widening, "\n") + "\n\nDo you want to apply them?").
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			ps.app.SetRoot(ps.flexRoot, true)
+			switch buttonIndex {
+			case 0:
+				ps.updatePackages(pending...)
+			case 1:
+				if len(others) > 0 {
+					ps.updatePackages(others...)
+				}
+			default:
+				return
+			}
+			ps.displayUpgradable()
+		})
+
+	ps.app.SetRoot(ask, true)
+}
+
+// removes unused runtimes and extensions in one transaction
+func (ps *UI) removeUnused(pkgs []Package) {
+	ps.runTransaction(func() error {
+		return ps.backend.UninstallAll(pkgs)
+	})
+	ps.refreshInstalledState()
+	ps.displayCleanup()
+}
+
+// deploys a specific commit of an installed package, e.g. to roll back a broken update
+func (ps *UI) deployCommit(pkg Package, commit string) {
+	ps.runTransaction(func() error {
+		return ps.backend.Deploy(pkg, commit)
+	})
+	ps.refreshInstalledState()
+}
+
+// adds missing remotes and installs missing apps of an imported state
+func (ps *UI) importState(diff StateDiff, removeExtra bool) {
+	ps.runTransaction(func() error {
+		return applyStateDiff(ps.backend, diff, removeExtra)
+	})
+	ps.refreshInstalledState()
+	ps.displayInstalled(false)
+}
+
+// installs from a bundle or flatpakref file, or adds the remote of a flatpakrepo file
+func (ps *UI) installBundle(b Bundle, installation string) {
+	ps.runTransaction(func() error {
+		if b.Kind == "flatpakrepo" {
+			return ps.backend.AddRemote(b.RemoteName, b.File, installation)
+		}
+		return ps.backend.InstallBundle(b, installation)
+	})
+	ps.refreshInstalledState()
+	if b.Kind == "flatpakrepo" {
+		ps.displayRemotes()
+	} else {
+		ps.displayInstalled(false)
+	}
 }
 
 // suspends UI and runs a command in the terminal
 func (ps *UI) runCommand(command string, args ...string) {
-	// suspend gui and run command in terminal
-	ps.app.Suspend(func() {
-
-		cmd := exec.Command(command, args...)
-		cmd.Stdin = os.Stdin
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
+	ps.runTransaction(func() error {
+		return runAttached(command, args...)
+	})
+}
 
-		// handle SIGINT and forward to the child process
-		cmd.Start()
-		quit := handleSigint(cmd)
-		err := cmd.Wait()
+// suspends UI and runs a function which may use the terminal
+func (ps *UI) runTransaction(f func() error) {
+	ps.app.Suspend(func() {
+		err := f()
 		if err != nil {
 			if err.Error() != "signal: interrupt" {
-				cmd.Stdout.Write([]byte("\n" + err.Error() + "\nPress ENTER to return to flatseek\n"))
-				r := bufio.NewReader(cmd.Stdin)
+				os.Stdout.Write([]byte("\n" + err.Error() + "\nPress ENTER to return to flatseek\n"))
+				r := bufio.NewReader(os.Stdin)
 				r.ReadLine()
 			}
 		}
-		quit <- true
 	})
 }
 
+// runs a command attached to our terminal and waits for it to finish
+func runAttached(command string, args ...string) error {
+	cmd := exec.Command(command, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	// handle SIGINT and forward to the child process
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	quit := handleSigint(cmd)
+	err := cmd.Wait()
+	quit <- true
+	return err
+}
+
 // handles SIGINT call and passes it to a cmd process
 func handleSigint(cmd *exec.Cmd) chan bool {
 	quit := make(chan bool, 1)
@@ -1,11 +1,173 @@
 package flatseek
 
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Ref identifies an app, runtime or extension within an installation
+type Ref struct {
+	Kind   string
+	ID     string
+	Arch   string
+	Branch string
+}
+
+// Package is an app, runtime or extension available on a remote or installed locally
 type Package struct {
-	Name        string
-	Description string
-	AppID       string
-	Version     string
-	Branch      string
-	Remote      string
-	IsInstalled bool
+	Ref
+	Name          string
+	Description   string
+	Version       string
+	Commit        string
+	LocalVersion  string
+	LocalCommit   string
+	Remote        string
+	Installation  string
+	InstalledSize string
+	DownloadSize  string
+	Metadata      string
+	EOL           string
+	Details       *AppDetails
+	IsInstalled   bool
+	Masked        bool
+	Pinned        bool
+	NewGrants     []string
+	GrantsUnknown bool
+}
+
+// ParseRef parses refs like "app/org.x.Y/x86_64/stable".
+// Partial refs without kind ("org.x.Y/x86_64/stable"), arch ("org.x.Y//stable") or
+// branch ("org.x.Y") are accepted as well.
+func ParseRef(s string) (Ref, error) {
+	ref := Ref{}
+	parts := strings.Split(strings.TrimSpace(s), "/")
+	if parts[0] == "app" || parts[0] == "runtime" {
+		ref.Kind = parts[0]
+		parts = parts[1:]
+	}
+	if len(parts) == 0 || len(parts) > 3 || parts[0] == "" {
+		return ref, fmt.Errorf("invalid ref: %q", s)
+	}
+
+	ref.ID = parts[0]
+	if len(parts) > 1 {
+		ref.Arch = parts[1]
+	}
+	if len(parts) > 2 {
+		ref.Branch = parts[2]
+	}
+	return ref, nil
+}
+
+// String returns the ref as kind/id/arch/branch, or id/arch/branch if the kind is unknown
+func (r Ref) String() string {
+	arch := r.Arch
+	if arch == "" {
+		arch = defaultArch()
+	}
+	ref := r.ID + "/" + arch + "/" + r.Branch
+	if r.Kind != "" {
+		ref = r.Kind + "/" + ref
+	}
+	return ref
+}
+
+// checks if two refs point to the same package; an unknown kind matches any kind
+func (r Ref) matches(o Ref) bool {
+	if r.Kind != "" && o.Kind != "" && r.Kind != o.Kind {
+		return false
+	}
+	return r.withKind("").String() == o.withKind("").String()
+}
+
+// returns the ref with the given kind
+func (r Ref) withKind(kind string) Ref {
+	r.Kind = kind
+	return r
+}
+
+// returns the key identifying a package in our lists and caches
+func (p Package) key() string {
+	return p.Ref.String() + "-" + p.Remote + "-" + p.Installation
+}
+
+// returns the size shown in our package list: disk usage if installed, otherwise the download size
+func (p Package) size() string {
+	if p.IsInstalled && p.InstalledSize != "" {
+		return p.InstalledSize
+	}
+	return p.DownloadSize
+}
+
+// converts a size printed by flatpak like "7.4 MB" to bytes; unknown sizes are 0
+func parseSize(size string) float64 {
+	// glib separates the unit with a no-break space, which Fields treats as space too
+	fields := strings.Fields(size)
+	if len(fields) == 0 {
+		return 0
+	}
+	value, unit := fields[0], ""
+	if len(fields) > 1 {
+		unit = fields[1]
+	}
+	v, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", "."), 64)
+	if err != nil {
+		return 0
+	}
+	switch strings.ToLower(unit) {
+	case "kb":
+		v *= 1000
+	case "mb":
+		v *= 1000 * 1000
+	case "gb":
+		v *= 1000 * 1000 * 1000
+	case "tb":
+		v *= 1000 * 1000 * 1000 * 1000
+	}
+	return v
+}
+
+// converts bytes to a size like flatpak prints them, e.g. "7.4 MB"
+func formatSize(size float64) string {
+	units := []string{"bytes", "kB", "MB", "GB"}
+	i := 0
+	for ; size >= 1000 && i < len(units)-1; i++ {
+		size /= 1000
+	}
+	if i == 0 {
+		return fmt.Sprintf("%.0f %s", size, units[i])
+	}
+	return fmt.Sprintf("%.1f %s", size, units[i])
+}
+
+// returns the flatpak architecture name of the machine we are running on
+func defaultArch() string {
+	switch runtime.GOARCH {
+	case "amd64":
+		return "x86_64"
+	case "arm64":
+		return "aarch64"
+	case "386":
+		return "i386"
+	}
+	return runtime.GOARCH
+}
+
+// returns the abbreviated form of a commit id
+func shortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
+
+// checks if two commit ids are the same, one of them might be abbreviated like flatpak lists them
+func sameCommit(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	return strings.HasPrefix(a, b) || strings.HasPrefix(b, a)
 }
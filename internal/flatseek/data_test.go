@@ -0,0 +1,96 @@
+package flatseek
+
+import "testing"
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		size string
+		want float64
+	}{
+		{"7.4 MB", 7.4e6},
+		{"7.4\u00a0MB", 7.4e6},
+		{" 1,5 GB ", 1.5e9},
+		{"512 kB", 512e3},
+		{"300 bytes", 300},
+		{"2 TB", 2e12},
+		{"", 0},
+		{"unknown", 0},
+	}
+	for _, tt := range tests {
+		if got := parseSize(tt.size); got != tt.want {
+			t.Errorf("parseSize(%q) = %v, want %v", tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestSameCommit(t *testing.T) {
+	full := "3f1c2a9d8e7b6c5a4f3e2d1c0b9a8f7e6d5c4b3a2f1e0d9c8b7a6f5e4d3c2b1a"
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{full, full, true},
+		{full, shortCommit(full), true},
+		{shortCommit(full), full, true},
+		{full, "9a8b7c6d5e4f", false},
+		{full, "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		if got := sameCommit(tt.a, tt.b); got != tt.want {
+			t.Errorf("sameCommit(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestParseRef(t *testing.T) {
+	tests := []struct {
+		ref     string
+		want    Ref
+		wantErr bool
+	}{
+		{"app/org.x.Y/x86_64/stable", Ref{Kind: "app", ID: "org.x.Y", Arch: "x86_64", Branch: "stable"}, false},
+		{"runtime/org.x.Platform/aarch64/46", Ref{Kind: "runtime", ID: "org.x.Platform", Arch: "aarch64", Branch: "46"}, false},
+		{"org.x.Y/x86_64/stable", Ref{ID: "org.x.Y", Arch: "x86_64", Branch: "stable"}, false},
+		{"org.x.Y//stable", Ref{ID: "org.x.Y", Branch: "stable"}, false},
+		{" org.x.Y ", Ref{ID: "org.x.Y"}, false},
+		{"", Ref{}, true},
+		{"app/", Ref{Kind: "app"}, true},
+		{"app/org.x.Y/x86_64/stable/extra", Ref{Kind: "app"}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRef(tt.ref)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseRef(%q) error = %v, want error %v", tt.ref, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseRef(%q) = %+v, want %+v", tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestRefMatches(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"app/org.x.Y/x86_64/stable", "app/org.x.Y/x86_64/stable", true},
+		{"app/org.x.Y/x86_64/stable", "org.x.Y/x86_64/stable", true},
+		{"org.x.Y//stable", "app/org.x.Y/" + defaultArch() + "/stable", true},
+		{"app/org.x.Y/x86_64/stable", "runtime/org.x.Y/x86_64/stable", false},
+		{"app/org.x.Y/x86_64/stable", "app/org.x.Y/x86_64/beta", false},
+		{"app/org.x.Y/x86_64/stable", "app/org.x.Y/aarch64/stable", false},
+		{"app/org.x.Y/x86_64/stable", "app/org.x.Z/x86_64/stable", false},
+	}
+	for _, tt := range tests {
+		a, _ := ParseRef(tt.a)
+		b, _ := ParseRef(tt.b)
+		if got := a.matches(b); got != tt.want {
+			t.Errorf("%q matches %q = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+		if got := b.matches(a); got != tt.want {
+			t.Errorf("%q matches %q = %v, want %v", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
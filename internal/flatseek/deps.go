@@ -0,0 +1,264 @@
+package flatseek
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// depNode is a ref in our dependency tree, or a group of refs if it has no ref
+type depNode struct {
+	Label     string
+	Ref       Ref
+	Installed bool
+	Children  []depNode
+}
+
+// returns the installed package with a ref
+func findInstalled(installed []Package, ref Ref) (Package, bool) {
+	for _, pkg := range installed {
+		if pkg.Ref.matches(ref) {
+			return pkg, true
+		}
+	}
+	return Package{}, false
+}
+
+// creates a node for a ref referenced in a metadata file like "org.gnome.Platform/x86_64/46"
+func refNode(label, s, kind string, installed []Package) (depNode, bool) {
+	ref, err := ParseRef(s)
+	if err != nil {
+		return depNode{}, false
+	}
+	ref = ref.withKind(kind)
+	_, found := findInstalled(installed, ref)
+	return depNode{Label: label, Ref: ref, Installed: found}, true
+}
+
+// returns the installed refs which provide an extension point, e.g. org.freedesktop.Platform.GL.default
+// for org.freedesktop.Platform.GL
+func installedExtensions(installed []Package, point string) []Package {
+	extensions := []Package{}
+	for _, pkg := range installed {
+		if pkg.Kind == "runtime" && (pkg.ID == point || strings.HasPrefix(pkg.ID, point+".")) {
+			extensions = append(extensions, pkg)
+		}
+	}
+	return extensions
+}
+
+// shown for extensions nothing installed references
+const unreferencedExtensionNote = "nothing installed references it, but it is an extension flatpak might still load"
+
+// checks if the metadata is the one of an extension, which names the ref it extends
+func isExtension(metadata string) bool {
+	_, ok := parseKeyFile(metadata)["ExtensionOf"]
+	return ok
+}
+
+// builds the dependencies of a package from its metadata: runtime, sdk and extensions.
+// the runtime's dependencies are added to its node up to the given depth
+func dependencyNode(b Backend, installed []Package, pkg Package, depth int) depNode {
+	node := depNode{Ref: pkg.Ref, Installed: pkg.IsInstalled}
+	if ipkg, found := findInstalled(installed, pkg.Ref); found {
+		pkg = ipkg
+		node.Installed = true
+	}
+
+	metadata, err := b.Metadata(pkg)
+	if err != nil {
+		node.Children = append(node.Children, depNode{Label: "[red]metadata unavailable: " + err.Error()})
+		return node
+	}
+	kf := parseKeyFile(metadata)
+	group := "Application"
+	if pkg.Kind == "runtime" {
+		group = "Runtime"
+	}
+
+	// runtime; runtimes name themselves as runtime
+	if n, ok := refNode("Runtime", kf[group]["runtime"], "runtime", installed); ok && !n.Ref.matches(pkg.Ref) {
+		if depth > 0 && n.Installed {
+			rpkg, _ := findInstalled(installed, n.Ref)
+			n.Children = dependencyNode(b, installed, rpkg, depth-1).Children
+		}
+		node.Children = append(node.Children, n)
+	}
+	if n, ok := refNode("SDK", kf[group]["sdk"], "runtime", installed); ok {
+		node.Children = append(node.Children, n)
+	}
+
+	// extensions provided for our extension points
+	for _, point := range extensionPoints(kf) {
+		ext := depNode{Label: "Extension point " + point}
+		for _, e := range installedExtensions(installed, point) {
+			ext.Children = append(ext.Children, depNode{Ref: e.Ref, Installed: true})
+		}
+		if len(ext.Children) > 0 {
+			node.Children = append(node.Children, ext)
+		}
+	}
+	return node
+}
+
+// returns the installed apps and runtimes which pull in a runtime or extension: as their runtime,
+// their sdk or as an extension of theirs
+func requiredBy(b Backend, installed []Package, runtime Package) ([]depNode, error) {
+	deps, err := installedDependencies(b, installed)
+	if err != nil {
+		return nil, err
+	}
+	labels := map[string]string{
+		"runtime":   "Runtime of",
+		"sdk":       "SDK of",
+		"extension": "Extension of",
+	}
+	nodes := []depNode{}
+	for _, d := range deps {
+		if d.To.key() == runtime.key() {
+			nodes = append(nodes, depNode{Label: labels[d.As], Ref: d.From.Ref, Installed: true})
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Ref.String() < nodes[j].Ref.String()
+	})
+	return nodes, nil
+}
+
+// returns the kind of a package, which tells the metadata group we read. packages found with flatpak search
+// have none, we take it from the installed ref or the remote; apps are assumed if that fails
+func packageKind(b Backend, installed []Package, pkg Package) string {
+	if pkg.Kind != "" {
+		return pkg.Kind
+	}
+	if ipkg, found := findInstalled(installed, pkg.Ref); found {
+		return ipkg.Kind
+	}
+	if info, err := b.Info(pkg); err == nil && info.Kind != "" {
+		return info.Kind
+	}
+	return "app"
+}
+
+// builds the dependency tree of a package. for runtimes and extensions, the installed apps and runtimes
+// pulling them in are added if "Compute Required by" is enabled in our settings
+func (ps *UI) dependencyTree(pkg Package) (depNode, error) {
+	installed, err := ps.backend.ListInstalled()
+	if err != nil {
+		return depNode{}, err
+	}
+
+	pkg.Kind = packageKind(ps.backend, installed, pkg)
+	root := dependencyNode(ps.backend, installed, pkg, 1)
+	if pkg.Kind != "runtime" {
+		return root, nil
+	}
+
+	reverse := depNode{Label: "Required by"}
+	switch {
+	case !ps.conf.ComputeRequiredBy:
+		reverse.Children = []depNode{{Label: "enable Compute \"Required by\" in the settings"}}
+	case !root.Installed:
+		reverse.Children = []depNode{{Label: "not installed"}}
+	default:
+		ipkg, _ := findInstalled(installed, pkg.Ref)
+		for _, p := range installed {
+			if p.Ref.matches(pkg.Ref) && p.Installation == pkg.Installation {
+				ipkg = p
+			}
+		}
+		if reverse.Children, err = requiredBy(ps.backend, installed, ipkg); err != nil {
+			return depNode{}, err
+		}
+		if len(reverse.Children) == 0 {
+			reverse.Children = []depNode{{Label: "nothing installed needs it, it can be removed safely"}}
+
+			// extensions might be loaded without being referenced, e.g. for the gpu or the theme of the host
+			if metadata, err := ps.backend.Metadata(ipkg); err != nil || isExtension(metadata) {
+				reverse.Children = []depNode{{Label: unreferencedExtensionNote}}
+			}
+		}
+	}
+	root.Children = append(root.Children, reverse)
+	return root, nil
+}
+
+// displays the dependency tree of the selected package
+func (ps *UI) displayDependencies() {
+	if ps.selectedPackage == nil {
+		return
+	}
+	pkg := *ps.selectedPackage
+	ps.tableDetails.SetTitle(" [::b]" + pkg.ID + " - Resolving dependencies... ")
+
+	go func() {
+		ps.locker.Lock()
+		ps.startSpinner()
+		defer func() {
+			ps.locker.Unlock()
+			ps.stopSpinner()
+		}()
+
+		root, err := ps.dependencyTree(pkg)
+		ps.app.QueueUpdateDraw(func() {
+			if err != nil {
+				ps.tableDetails.SetTitle(" [::b]Error ")
+				ps.displayMessage(err.Error(), true)
+				return
+			}
+			ps.drawDependencies(root)
+		})
+	}()
+}
+
+// returns the tree node of a dependency and its children
+func (ps *UI) depTreeNode(n depNode) *tview.TreeNode {
+	text := n.Label
+	if n.Ref.ID != "" {
+		if text != "" {
+			text += ": "
+		}
+		text += "[::b]" + n.Ref.String() + "[::-]"
+		if n.Installed {
+			text += " [green](installed)"
+		} else {
+			text += " [red](not installed)"
+		}
+	}
+	color := tcell.ColorWhite
+	if n.Ref.ID == "" {
+		color = ps.conf.Colors().PackagelistHeader
+	}
+	node := tview.NewTreeNode(text).
+		SetColor(color).
+		SetSelectable(true)
+	for _, c := range n.Children {
+		node.AddChild(ps.depTreeNode(c))
+	}
+	return node
+}
+
+// draws the dependency tree
+func (ps *UI) drawDependencies(root depNode) {
+	rootNode := ps.depTreeNode(root).
+		SetColor(ps.conf.Colors().Accent)
+	ps.treeDeps.SetRoot(rootNode).
+		SetCurrentNode(rootNode).
+		SetTitle(" [::b]" + ps.conf.Glyphs().Package + "Dependencies of " + root.Ref.ID + " ")
+
+	ps.flexRight.Clear()
+	ps.flexRight.AddItem(ps.treeDeps, 0, 1, false)
+	ps.app.SetFocus(ps.treeDeps)
+}
+
+// closes the dependency tree and shows the package details again
+func (ps *UI) closeDependencies() {
+	ps.flexRight.Clear()
+	ps.flexRight.AddItem(ps.tableDetails, 0, 1, false)
+	ps.app.SetFocus(ps.tablePackages)
+	if ps.selectedPackage != nil {
+		ps.drawPackageInfo(*ps.selectedPackage, ps.width)
+	}
+}
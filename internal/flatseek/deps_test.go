@@ -0,0 +1,66 @@
+package flatseek
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRequiredBy(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want []string // label: ref
+	}{
+		{"runtime/org.gnome.Platform/x86_64/46", []string{"Runtime of: app/org.gnome.Calculator/x86_64/stable"}},
+		{"runtime/org.gnome.Platform.Locale/x86_64/46", []string{"Extension of: runtime/org.gnome.Platform/x86_64/46"}},
+		{"runtime/org.gnome.Platform/x86_64/44", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			f := newTestBackend(t)
+			installed, err := f.ListInstalled()
+			if err != nil {
+				t.Fatal(err)
+			}
+			ref, _ := ParseRef(tt.ref)
+			pkg, found := findInstalled(installed, ref)
+			if !found {
+				t.Fatalf("%s is not installed", tt.ref)
+			}
+
+			nodes, err := requiredBy(f, installed, pkg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := []string{}
+			for _, n := range nodes {
+				got = append(got, n.Label+": "+n.Ref.String())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("requiredBy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPackageKind(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{"runtime/org.gnome.Platform/x86_64/46", "runtime"},
+		{"org.gnome.Platform/x86_64/45", "runtime"},
+		{"org.gimp.GIMP/x86_64/stable", "app"},
+		{"org.example.Unknown/x86_64/stable", "app"},
+	}
+	for _, tt := range tests {
+		f := newTestBackend(t)
+		installed, err := f.ListInstalled()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ref, _ := ParseRef(tt.ref)
+		if got := packageKind(f, installed, Package{Ref: ref}); got != tt.want {
+			t.Errorf("packageKind(%q) = %q, want %q", tt.ref, got, tt.want)
+		}
+	}
+}
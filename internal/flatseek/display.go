@@ -3,6 +3,7 @@ package flatseek
 import (
 	"os/exec"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
@@ -51,11 +52,11 @@ func (ps *UI) displayPackages(text string) {
 			})
 		}
 
-		// add local-only (not found in repo not AUR)
+		// add local-only (not found on any remote)
 		for _, lpkg := range localPackages {
 			found := false
 			for _, pkg := range packages {
-				if pkg.Name == lpkg.Name {
+				if pkg.Ref.matches(lpkg.Ref) && (pkg.Installation == "" || pkg.Installation == lpkg.Installation) {
 					found = true
 					break
 				}
@@ -119,28 +120,33 @@ func (ps *UI) displayPackages(text string) {
 // 	}
 // }
 
-// retrieves package information from repo/AUR and displays them
+// details we could not retrieve completely are only cached for a short time
+const detailsRetryDelay = 30 * time.Second
+
+// retrieves package information and displays them
 func (ps *UI) displayPackageInfo(row, column int) {
 	if row == -1 || row+1 > ps.tablePackages.GetRowCount() {
 		return
 	}
 	ps.tableDetails.Clear().
 		SetTitle("")
-	pkg := ps.tablePackages.GetCell(row, 0).Text
-	source := ps.tablePackages.GetCell(row, 1).Text
+	key, ok := ps.tablePackages.GetCell(row, 0).Reference.(string)
+	if !ok {
+		return
+	}
+	id := ps.tablePackages.GetCell(row, 0).Text
 
 	var info *Package = nil
 
 	showFunc := func() {
-		for _, shown := range ps.shownPackages {
-			if shown.AppID == pkg {
-				info = &shown
-				break
+		if info == nil {
+			for _, shown := range ps.shownPackages {
+				if shown.key() == key {
+					info = &shown
+					break
+				}
 			}
 		}
-		// if !ps.isPackageSelected(pkg, false) {
-		//	return
-		//}
 		if info == nil {
 			ps.tableDetails.SetTitle(" [red]Error ")
 			ps.tableDetails.SetCellSimple(0, 0, "[red]Package not found")
@@ -150,23 +156,24 @@ func (ps *UI) displayPackageInfo(row, column int) {
 		ps.drawPackageInfo(*info, ps.width)
 	}
 
-	// if infoCached, found := ps.cacheInfo.Get(pkg + "-" + source); found {
-	// 	info = []Package{infoCached.(InfoRecord)}
-	// 	showFunc()
-	// 	return
-	// }
+	// cached entries of the installed list lack the details, those we retrieved are cached separately
+	if infoCached, found := ps.cacheInfo.Get(key + "#details#"); found {
+		cached := infoCached.(Package)
+		info = &cached
+		showFunc()
+		return
+	}
 
 	go func() {
-		if source == "AUR" {
-			time.Sleep(time.Duration(ps.conf.AurSearchDelay) * time.Millisecond)
-		}
+		// wait a bit so we don't call flatpak for each row we are scrolling through
+		time.Sleep(time.Duration(ps.conf.AurSearchDelay) * time.Millisecond)
 
-		if !ps.isPackageSelected(pkg, true) {
+		if !ps.isPackageSelected(key, true) {
 			return
 		}
 
 		ps.app.QueueUpdateDraw(func() {
-			ps.tableDetails.SetTitle(" [::b]" + pkg + " - Retrieving data... ")
+			ps.tableDetails.SetTitle(" [::b]" + id + " - Retrieving data... ")
 		})
 
 		ps.locker.Lock()
@@ -176,17 +183,63 @@ func (ps *UI) displayPackageInfo(row, column int) {
 			ps.stopSpinner()
 		}()
 
-		if !ps.conf.DisableCache && info != nil {
-			ps.cacheInfo.Set(pkg+"-"+info.Remote, info, time.Duration(ps.conf.CacheExpiry)*time.Minute)
+		// the selection might have changed while we were waiting for the lock
+		if !ps.isPackageSelected(key, true) {
+			return
+		}
+
+		// retrieve sizes, metadata for the permissions and AppStream details of the selected package only;
+		// we show what we have if that fails (e.g. when offline) and try again shortly after
+		expiry := time.Duration(ps.conf.CacheExpiry) * time.Minute
+		for _, shown := range ps.shownPackages {
+			if shown.key() != key {
+				continue
+			}
+			detailed := shown
+			pkg, err := ps.backend.Info(shown)
+			if err == nil {
+				detailed = pkg
+			}
+			metadata, mErr := ps.backend.Metadata(detailed)
+			if mErr == nil {
+				detailed.Metadata = metadata
+			}
+			details, dErr := ps.backend.Details(detailed)
+			if dErr == nil {
+				detailed.Details = &details
+			}
+			if err != nil || mErr != nil || dErr != nil {
+				expiry = detailsRetryDelay
+			}
+			info = &detailed
+			break
 		}
 
 		// draw results
 		ps.app.QueueUpdateDraw(func() {
+			if info != nil {
+				ps.updatePackageSize(key, *info)
+				if !ps.conf.DisableCache {
+					ps.cacheInfo.Set(key+"#details#", *info, expiry)
+				}
+			}
 			showFunc()
 		})
 	}()
 }
 
+// sets the sizes of a shown package once we retrieved them and updates its row
+func (ps *UI) updatePackageSize(key string, info Package) {
+	for i := range ps.shownPackages {
+		if ps.shownPackages[i].key() != key || i+1 >= ps.tablePackages.GetRowCount() {
+			continue
+		}
+		ps.shownPackages[i].InstalledSize = info.InstalledSize
+		ps.shownPackages[i].DownloadSize = info.DownloadSize
+		ps.tablePackages.GetCell(i+1, 4).SetText(ps.shownPackages[i].size())
+	}
+}
+
 // displays status bar with error message
 func (ps *UI) displayMessage(message string, isError bool) {
 	txt := message
@@ -220,12 +273,19 @@ func (ps *UI) displayHelp() {
 		SetCellSimple(6, 0, "CTRL+U: Perform sysupgrade").
 		SetCellSimple(7, 0, "CTRL+A: Perform AUR upgrade (if configured)").
 		SetCellSimple(8, 0, "CTRL+W: Wipe cache").
-		SetCellSimple(9, 0, "CTRL+P: Show PKGBUILD for selected package").
-		SetCellSimple(10, 0, "CTRL+O: Open URL for selected package").
+		SetCellSimple(9, 0, "CTRL+P: Show metadata / manifest of selected package").
+		SetCellSimple(10, 0, "CTRL+O: Open homepage of selected package").
 		SetCellSimple(11, 0, "CTRL+G: Show list of upgradeable packages").
 		SetCellSimple(12, 0, "CTRL+L: Show list of all installed packages").
-		SetCellSimple(14, 0, "CTRL+Q / ESC: Quit").
-		SetCell(16, 0, &tview.TableCell{
+		SetCellSimple(13, 0, "CTRL+R: Show and manage remotes").
+		SetCellSimple(14, 0, "CTRL+E: Edit permission overrides for selected app").
+		SetCellSimple(15, 0, "CTRL+T: Show running instances").
+		SetCellSimple(16, 0, "CTRL+X: Export / import installed apps").
+		SetCellSimple(17, 0, "CTRL+F: Open .flatpak / .flatpakref / .flatpakrepo file").
+		SetCellSimple(18, 0, "CTRL+D: Show dependency tree of selected package").
+		SetCellSimple(19, 0, "CTRL+K: Clean up unused runtimes and extensions").
+		SetCellSimple(20, 0, "CTRL+Q / ESC: Quit").
+		SetCell(22, 0, &tview.TableCell{
 			Text:            "For detailed instructions, please check the man page or visit the [::b]Wiki",
 			Color:           tcell.ColorWhite,
 			BackgroundColor: ps.conf.Colors().DefaultBackground,
@@ -271,12 +331,12 @@ func (ps *UI) displayAbout() {
 	}
 }
 
-// checks if a given package is currently selected in the package list
-func (ps *UI) isPackageSelected(pkg string, queue bool) bool {
+// checks if a package with the given key is currently selected in the package list
+func (ps *UI) isPackageSelected(key string, queue bool) bool {
 	var sel string
 	f := func() {
 		crow, _ := ps.tablePackages.GetSelection()
-		sel = ps.tablePackages.GetCell(crow, 0).Text
+		sel, _ = ps.tablePackages.GetCell(crow, 0).Reference.(string)
 	}
 
 	if queue {
@@ -285,7 +345,7 @@ func (ps *UI) isPackageSelected(pkg string, queue bool) bool {
 		f()
 	}
 
-	return sel == pkg
+	return sel == key
 }
 
 // displays a list of updatable packages
@@ -300,56 +360,109 @@ func (ps *UI) displayUpgradable() {
 		return
 	}
 
-	// go func() {
-	// 	ps.locker.Lock()
-	// 	ps.startSpinner()
-	// 	defer ps.stopSpinner()
-	// 	defer ps.locker.Unlock()
-	//
-	// 	h, err := syncToTempDB(ps.conf.PacmanConfigPath, ps.filterRepos)
-	// 	if err != nil {
-	// 		ps.app.QueueUpdateDraw(func() {
-	// 			ps.tableDetails.SetTitle(" [::b]Error ")
-	//
-	// 			lines := strings.Split(err.Error(), "\n")
-	// 			for i, line := range lines {
-	// 				ps.tableDetails.SetCell(i+1, 0, &tview.TableCell{
-	// 					Text:            line,
-	// 					Color:           tcell.ColorRed,
-	// 					BackgroundColor: ps.conf.Colors().DefaultBackground,
-	// 				})
-	// 			}
-	// 			ps.displayMessage("Failed to sync temporary DB's", true)
-	// 		})
-	// 		return
-	// 	}
-	//
-	// 	up, nf := getUpgradable(h, ps.conf.ComputeRequiredBy)
-	// 	aurPkgs := infoAur(ps.conf.AurRpcUrl, ps.conf.AurTimeout, nf...)
-	// 	for _, aurPkg := range aurPkgs.Results {
-	// 		for i := 0; i < len(up); i++ {
-	// 			if up[i].Source == "local" && up[i].Name == aurPkg.Name {
-	// 				if alpm.VerCmp(aurPkg.Version, up[i].LocalVersion) > 0 {
-	// 					up[i].Description = aurPkg.Description
-	// 					up[i].Version = aurPkg.Version
-	// 					up[i].Source = "AUR"
-	// 				}
-	// 			}
-	// 		}
-	// 	}
-	// 	foundUp := []InfoRecord{}
-	// 	for _, pkg := range up {
-	// 		if pkg.Version != pkg.LocalVersion {
-	// 			foundUp = append(foundUp, pkg)
-	// 		}
-	// 	}
-	// 	if !ps.conf.DisableCache {
-	// 		ps.cacheInfo.Set("#upgrades#", foundUp, time.Duration(ps.conf.CacheExpiry)*time.Minute)
-	// 	}
-	// 	ps.app.QueueUpdateDraw(func() {
-	// 		ps.drawUpgradable(foundUp, false)
-	// 	})
-	// }()
+	go func() {
+		ps.locker.Lock()
+		ps.startSpinner()
+		defer ps.stopSpinner()
+		defer ps.locker.Unlock()
+
+		up, err := ps.findUpgradable()
+		if err != nil {
+			ps.app.QueueUpdateDraw(func() {
+				ps.tableDetails.SetTitle(" [::b]Error ")
+
+				lines := strings.Split(err.Error(), "\n")
+				for i, line := range lines {
+					ps.tableDetails.SetCell(i+1, 0, &tview.TableCell{
+						Text:            line,
+						Color:           tcell.ColorRed,
+						BackgroundColor: ps.conf.Colors().DefaultBackground,
+					})
+				}
+				ps.displayMessage("Failed to search for updates", true)
+			})
+			return
+		}
+
+		if !ps.conf.DisableCache {
+			ps.cacheInfo.Set("#upgrades#", up, time.Duration(ps.conf.CacheExpiry)*time.Minute)
+		}
+		ps.app.QueueUpdateDraw(func() {
+			ps.drawUpgradable(up, false)
+		})
+	}()
+}
+
+// returns the available updates along with their installed version, masked state and the sandbox grants they add
+func (ps *UI) findUpgradable() ([]Package, error) {
+	up, err := ps.backend.ListUpdates()
+	if err == nil {
+		err = ps.loadInstalledRefs()
+	}
+	var masks []Mask
+	if err == nil {
+		masks, err = ps.backend.Masks()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// add installed version / commit
+	for i := 0; i < len(up); i++ {
+		if ipkg, found := ps.getInstalledRef(up[i]); found {
+			up[i].LocalVersion = ipkg.Version
+			up[i].LocalCommit = ipkg.Commit
+			up[i].InstalledSize = ipkg.InstalledSize
+		}
+		up[i].Masked = isMasked(up[i], masks)
+		up[i].Pinned = isPinned(up[i], masks)
+
+		// compare the sandbox of pending updates; if the metadata is unavailable (e.g. offline) we can't tell
+		if !up[i].Masked {
+			installed, available, err := metadataPair(ps.backend, up[i])
+			if err != nil {
+				up[i].GrantsUnknown = true
+				continue
+			}
+			up[i].NewGrants = sandboxGrants(installed, available)
+		}
+	}
+	sort.Slice(up, func(i, j int) bool {
+		return up[i].ID < up[j].ID
+	})
+	return up, nil
+}
+
+// looks for updates and applies the pending ones through our permission check
+func (ps *UI) upgradeAll() {
+	go func() {
+		ps.locker.Lock()
+		ps.startSpinner()
+		defer ps.stopSpinner()
+		defer ps.locker.Unlock()
+
+		up, err := ps.findUpgradable()
+		ps.app.QueueUpdateDraw(func() {
+			if err != nil {
+				ps.displayMessage(err.Error(), true)
+				return
+			}
+			if !ps.conf.DisableCache {
+				ps.cacheInfo.Set("#upgrades#", up, time.Duration(ps.conf.CacheExpiry)*time.Minute)
+			}
+			pending := []Package{}
+			for _, pkg := range up {
+				if !pkg.Masked {
+					pending = append(pending, pkg)
+				}
+			}
+			if len(pending) == 0 {
+				ps.displayMessage("No upgrades found", false)
+				return
+			}
+			ps.sysupgrade(pending)
+		})
+	}()
 }
 
 // displays list of installed packages
@@ -366,55 +479,48 @@ func (ps *UI) displayInstalled(displayUpdatesAfter bool) {
 		return
 	}
 
-	// go func() {
-	// 	ps.locker.Lock()
-	// 	ps.startSpinner()
-	// 	defer func() {
-	// 		ps.locker.Unlock()
-	// 		ps.stopSpinner()
-	// 	}()
-	//
-	// 	in, nf := getInstalled(ps.alpmHandle, ps.conf.ComputeRequiredBy)
-	// 	aurPkgs := ps.getInfo("AUR", nf...).Results
-	// 	for _, aurPkg := range aurPkgs {
-	// 		for i := 0; i < len(in); i++ {
-	// 			if in[i].Source == "local" && in[i].Name == aurPkg.Name {
-	// 				in[i].Description = aurPkg.Description
-	// 				in[i].Version = aurPkg.Version
-	// 				in[i].Source = "AUR"
-	// 				in[i].Popularity = aurPkg.Popularity
-	// 				in[i].NumVotes = aurPkg.NumVotes
-	// 				in[i].Maintainer = aurPkg.Maintainer
-	// 			}
-	// 		}
-	// 	}
-	//
-	// 	packages := []Package{}
-	// 	for _, pkg := range in {
-	// 		packages = append(packages, Package{
-	// 			Name:         pkg.Name,
-	// 			Source:       pkg.Source,
-	// 			IsInstalled:  true,
-	// 			LastModified: pkg.LastModified,
-	// 			Popularity:   pkg.Popularity,
-	// 		})
-	// 		if !ps.conf.DisableCache {
-	// 			ps.cacheInfo.Set(pkg.Name+"-"+pkg.Source, pkg, time.Duration(ps.conf.CacheExpiry)*time.Minute)
-	// 		}
-	// 	}
-	// 	if !ps.conf.DisableCache {
-	// 		ps.cacheSearch.Set("#installed#", packages, time.Duration(ps.conf.CacheExpiry)*time.Minute)
-	// 	}
-	// 	ps.shownPackages = packages
-	// 	ps.app.QueueUpdateDraw(func() {
-	// 		ps.drawPackageListContent(packages, ps.conf.PackageColumnWidth)
-	// 		if displayUpdatesAfter {
-	// 			ps.displayUpgradable()
-	// 		} else {
-	// 			ps.tablePackages.Select(1, 0)
-	// 		}
-	// 	})
-	// }()
+	go func() {
+		ps.locker.Lock()
+		ps.startSpinner()
+		defer func() {
+			ps.locker.Unlock()
+			ps.stopSpinner()
+		}()
+
+		packages, err := ps.backend.ListInstalled()
+		if err != nil {
+			ps.app.QueueUpdateDraw(func() {
+				ps.tablePackages.Clear()
+				ps.displayMessage(err.Error(), true)
+			})
+			return
+		}
+		ps.setInstalledRefs(packages)
+
+		// sort list by id, apps and runtimes of different installations are kept next to each other
+		sort.Slice(packages, func(i, j int) bool {
+			if packages[i].ID == packages[j].ID {
+				return packages[i].Ref.String()+packages[i].Installation < packages[j].Ref.String()+packages[j].Installation
+			}
+			return packages[i].ID < packages[j].ID
+		})
+
+		if !ps.conf.DisableCache {
+			for _, pkg := range packages {
+				ps.cacheInfo.Set(pkg.key(), pkg, time.Duration(ps.conf.CacheExpiry)*time.Minute)
+			}
+			ps.cacheSearch.Set("#installed#", packages, time.Duration(ps.conf.CacheExpiry)*time.Minute)
+		}
+		ps.app.QueueUpdateDraw(func() {
+			ps.shownPackages = packages
+			ps.drawPackageListContent(packages, ps.conf.PackageColumnWidth)
+			if displayUpdatesAfter {
+				ps.displayUpgradable()
+			} else {
+				ps.tablePackages.Select(1, 0)
+			}
+		})
+	}()
 }
 
 // auto-complete function for our input field
@@ -28,6 +28,11 @@ func (ps *UI) drawSettingsFields(disableAur, disableCache, separateAurCommands,
 	cIndex := util.IndexOf(config.ColorSchemes(), ps.conf.ColorScheme)
 	bIndex := util.IndexOf(config.BorderStyles(), ps.conf.BorderStyle)
 	gIndex := util.IndexOf(config.GlyphStyles(), ps.conf.GlyphStyle)
+	installations := append([]string{"All"}, installationIDs()...)
+	iIndex := util.IndexOf(installations, ps.installation)
+	if iIndex < 0 {
+		iIndex = 0
+	}
 
 	// handle text/drop-down field changes
 	sc := func(txt string) {
@@ -86,6 +91,11 @@ func (ps *UI) drawSettingsFields(disableAur, disableCache, separateAurCommands,
 				ps.settingsChanged = true
 			}
 		}).
+		AddDropDown("Installation: ", installations, iIndex, func(text string, index int) {
+			if text != ps.installation && (text != "All" || ps.installation != "") {
+				ps.settingsChanged = true
+			}
+		}).
 		AddCheckbox("Enable Auto-suggest: ", ps.conf.EnableAutoSuggest, func(checked bool) {
 			ps.settingsChanged = true
 		}).
@@ -166,7 +176,7 @@ func (ps *UI) drawPackageInfo(pkg Package, width int) {
 
 	// clear content and set name
 	ps.tableDetails.Clear().
-		SetTitle(" [::b]" + ps.conf.Glyphs().Package + pkg.AppID + " ")
+		SetTitle(" [::b]" + ps.conf.Glyphs().Package + pkg.ID + " ")
 	r := 0
 	ln := 0
 
@@ -227,6 +237,47 @@ func (ps *UI) drawPackageInfo(pkg Package, width int) {
 			}
 		}
 	}
+	// latest releases
+	if pkg.Details != nil && len(pkg.Details.Releases) > 0 {
+		r = ps.drawReleases(pkg.Details.Releases, width, r+1)
+	}
+	// sandbox permissions
+	if pkg.Metadata != "" {
+		r = ps.drawPermissions(parsePermissions(pkg.Metadata), r+1)
+	}
+
+	// run installed apps
+	if ps.pkgCheckInstalled(pkg) && pkg.Kind != "runtime" {
+		r++
+		ps.tableDetails.SetCell(r, 0, &tview.TableCell{
+			Text:            " [::b]Run",
+			Align:           tview.AlignCenter,
+			Color:           ps.conf.Colors().SettingsFieldText,
+			BackgroundColor: ps.conf.Colors().SearchBar,
+			Clicked: func() bool {
+				ps.displayRunDialog(pkg)
+				return true
+			},
+		})
+		r++
+	}
+
+	// commit history of installed packages
+	if ps.pkgCheckInstalled(pkg) {
+		r++
+		ps.tableDetails.SetCell(r, 0, &tview.TableCell{
+			Text:            " [::b]History",
+			Align:           tview.AlignCenter,
+			Color:           ps.conf.Colors().SettingsFieldText,
+			BackgroundColor: ps.conf.Colors().SearchBar,
+			Clicked: func() bool {
+				ps.displayHistory(pkg)
+				return true
+			},
+		})
+		r++
+	}
+
 	// check if we got more lines than current screen height
 	_, _, _, height := ps.tableDetails.GetInnerRect()
 	ps.tableDetailsMore = false
@@ -236,6 +287,89 @@ func (ps *UI) drawPackageInfo(pkg Package, width int) {
 	ps.tableDetails.ScrollToBeginning()
 }
 
+// draws the sandbox permissions of a package starting at row r, dangerous ones are highlighted.
+// returns the next free row
+func (ps *UI) drawPermissions(perms Permissions, r int) int {
+	first := true
+	for _, f := range perms.fields() {
+		if len(f.values) == 0 {
+			continue
+		}
+		if first {
+			ps.tableDetails.SetCell(r, 0, &tview.TableCell{
+				Text:            "[::b]Permissions",
+				Color:           ps.conf.Colors().Title,
+				BackgroundColor: ps.conf.Colors().DefaultBackground,
+			})
+			r++
+			first = false
+		}
+		ps.tableDetails.SetCell(r, 0, &tview.TableCell{
+			Text:            "[::b]" + f.name,
+			Color:           ps.conf.Colors().Accent,
+			BackgroundColor: ps.conf.Colors().DefaultBackground,
+		})
+		for i, v := range f.values {
+			if i > 0 {
+				ps.tableDetails.SetCellSimple(r, 0, "")
+			}
+			cell := &tview.TableCell{
+				Text:            v,
+				Color:           tcell.ColorWhite,
+				BackgroundColor: ps.conf.Colors().DefaultBackground,
+			}
+			if isDangerousPermission(f.name, v) {
+				cell.SetText("[::b]" + v + " (!)").
+					SetTextColor(ps.conf.Colors().PackagelistHeader)
+			}
+			ps.tableDetails.SetCell(r, 1, cell)
+			r++
+		}
+	}
+	return r
+}
+
+// draws the latest releases of an app with their release notes, returns the next row
+func (ps *UI) drawReleases(releases []Release, width, r int) int {
+	ps.tableDetails.SetCell(r, 0, &tview.TableCell{
+		Text:            "[::b]Releases",
+		Color:           ps.conf.Colors().Title,
+		BackgroundColor: ps.conf.Colors().DefaultBackground,
+	})
+	r++
+	maxLen := len("Releases")
+	for _, rel := range releases {
+		maxLen = max(maxLen, len(rel.Version))
+	}
+	w := width - (int(float64(width)*(float64(ps.leftProportion)/10)) + maxLen + 7) // subtract left box, borders, padding and first column
+	for _, rel := range releases {
+		ps.tableDetails.SetCell(r, 0, &tview.TableCell{
+			Text:            "[::b]" + rel.Version,
+			Color:           ps.conf.Colors().Accent,
+			BackgroundColor: ps.conf.Colors().DefaultBackground,
+		})
+		ps.tableDetails.SetCell(r, 1, &tview.TableCell{
+			Text:            "[::b]" + rel.Date,
+			Color:           tcell.ColorWhite,
+			BackgroundColor: ps.conf.Colors().DefaultBackground,
+		})
+		r++
+		if rel.Description == "" {
+			continue
+		}
+		for _, l := range tview.WordWrap(tview.Escape(rel.Description), w) {
+			ps.tableDetails.SetCellSimple(r, 0, "")
+			ps.tableDetails.SetCell(r, 1, &tview.TableCell{
+				Text:            l,
+				Color:           tcell.ColorWhite,
+				BackgroundColor: ps.conf.Colors().DefaultBackground,
+			})
+			r++
+		}
+	}
+	return r
+}
+
 // draw list of upgradable packages
 func (ps *UI) drawUpgradable(up []Package, cached bool) {
 	ps.tableDetails.Clear().
@@ -247,7 +381,7 @@ func (ps *UI) drawUpgradable(up []Package, cached bool) {
 	}
 
 	// header
-	columns := []string{"Package  ", "Remote  ", "New version  ", "Installed version", ""}
+	columns := []string{"Package  ", "Remote  ", "New version  ", "Installed version  ", "", "", ""}
 	for i, col := range columns {
 		hcell := &tview.TableCell{
 			Text:            col,
@@ -257,16 +391,47 @@ func (ps *UI) drawUpgradable(up []Package, cached bool) {
 		ps.tableDetails.SetCell(0, i, hcell)
 	}
 
-	// lines (not ignored)
+	// lines (not ignored) along with the permissions they add
 	r := 1
+	pending := []Package{}
 	for i := 0; i < len(up); i++ {
+		if up[i].Masked {
+			continue
+		}
 		r++
 		ps.drawUpgradeableLine(up[i], r, false)
+		pending = append(pending, up[i])
+		grants := up[i].NewGrants
+		if up[i].GrantsUnknown {
+			grants = []string{"[red]permissions unknown, the metadata could not be compared"}
+		}
+		for _, grant := range grants {
+			r++
+			ps.tableDetails.SetCell(r, 0, &tview.TableCell{
+				Text:            "  adds",
+				Color:           ps.conf.Colors().PackagelistHeader,
+				BackgroundColor: ps.conf.Colors().DefaultBackground,
+			})
+			ps.tableDetails.SetCell(r, 1, &tview.TableCell{
+				Text:            "[::b]" + grant,
+				Color:           ps.conf.Colors().PackagelistHeader,
+				BackgroundColor: ps.conf.Colors().DefaultBackground,
+			})
+		}
+	}
+
+	// lines (ignored)
+	for i := 0; i < len(up); i++ {
+		if !up[i].Masked {
+			continue
+		}
+		r++
+		ps.drawUpgradeableLine(up[i], r, true)
 	}
 
 	// no updates found message else sysupgrade button
 	r += 2
-	if len(up) == 0 {
+	if len(pending) == 0 {
 		ps.tableDetails.SetCell(r, 0, &tview.TableCell{
 			Text:            "No upgrades found",
 			Color:           ps.conf.Colors().PackagelistHeader,
@@ -279,14 +444,25 @@ func (ps *UI) drawUpgradable(up []Package, cached bool) {
 			Color:           ps.conf.Colors().SettingsFieldText,
 			BackgroundColor: ps.conf.Colors().SearchBar,
 			Clicked: func() bool {
-				ps.performUpgrade(false)
-				ps.cacheInfo.Delete("#upgrades#")
-				ps.displayUpgradable()
+				ps.sysupgrade(pending)
 				return true
 			},
 		})
 	}
 
+	// masks and pins button
+	r += 2
+	ps.tableDetails.SetCell(r, 0, &tview.TableCell{
+		Text:            " [::b]Masks / pins",
+		Color:           ps.conf.Colors().SettingsFieldText,
+		BackgroundColor: ps.conf.Colors().SearchBar,
+		Align:           tview.AlignCenter,
+		Clicked: func() bool {
+			ps.displayMasks()
+			return true
+		},
+	})
+
 	// refresh button
 	if cached {
 		r += 2
@@ -326,20 +502,16 @@ func (ps *UI) drawUpgradeableLine(up Package, lNum int, ignored bool) {
 		BackgroundColor: ps.conf.Colors().DefaultBackground,
 	}
 	cellVnew := &tview.TableCell{
-		Text:            "[::b]" + up.Version,
+		Text:            "[::b]" + versionOrCommit(up.Version, up.Commit, up.LocalVersion),
 		Color:           ps.conf.Colors().PackagelistSourceRepository,
 		BackgroundColor: ps.conf.Colors().DefaultBackground,
 		Clicked: func() bool {
-			if ps.conf.ShowPkgbuildInternally {
-				ps.selectedPackage = &up
-			} else {
-				// ps.runCommand(util.Shell(), "-c", ps.getPkgbuildCommand(up.Remote, up.PackageBase))
-			}
+			ps.displayMetadataDiff(up)
 			return true
 		},
 	}
 	cellVold := &tview.TableCell{
-		Text:            up.Version,
+		Text:            versionOrCommit(up.LocalVersion, up.LocalCommit, up.Version),
 		Color:           ps.conf.Colors().PackagelistSourceAUR,
 		BackgroundColor: ps.conf.Colors().DefaultBackground,
 	}
@@ -349,20 +521,57 @@ func (ps *UI) drawUpgradeableLine(up Package, lNum int, ignored bool) {
 		SetCell(lNum, 2, cellVnew).
 		SetCell(lNum, 3, cellVold)
 
-	// rebuild button for AUR packages
-	if up.Remote == "AUR" && !ignored {
-		cellRebuild := &tview.TableCell{
-			Text:            " [::b]Rebuild / Update",
+	// update button for this ref only
+	if !ignored {
+		cellUpdate := &tview.TableCell{
+			Text:            " [::b]Update",
 			Color:           ps.conf.Colors().SettingsFieldText,
 			BackgroundColor: ps.conf.Colors().SearchBar,
 			Clicked: func() bool {
-				ps.installPackage(up, false)
-				ps.cacheInfo.Delete("#upgrades#")
-				ps.displayUpgradable()
+				ps.sysupgrade([]Package{up})
 				return true
 			},
 		}
-		ps.tableDetails.SetCell(lNum, 4, cellRebuild)
+		ps.tableDetails.SetCell(lNum, 4, cellUpdate)
+	}
+
+	// mask apps / pin runtimes, or unmask / unpin
+	mask := maskForPackage(up)
+	text := " [::b]Mask"
+	if mask.Pin {
+		text = " [::b]Pin"
+	}
+	if up.Pinned {
+		text = " [::b]Unpin"
+	}
+	if ignored {
+		text = " [::b]Unmask"
+	}
+	ps.tableDetails.SetCell(lNum, 5, &tview.TableCell{
+		Text:            text,
+		Color:           ps.conf.Colors().SettingsFieldText,
+		BackgroundColor: ps.conf.Colors().SearchBar,
+		Clicked: func() bool {
+			switch {
+			case ignored:
+				ps.unmaskPackage(up)
+			case up.Pinned:
+				ps.unpinPackage(up)
+			default:
+				ps.changeMask(mask, false)
+			}
+			ps.displayUpgradable()
+			return true
+		},
+	})
+
+	// pinned runtimes are still updated, they are just kept from being removed as unused
+	if up.Pinned {
+		ps.tableDetails.SetCell(lNum, 6, &tview.TableCell{
+			Text:            "[::b]pinned",
+			Color:           ps.conf.Colors().PackagelistHeader,
+			BackgroundColor: ps.conf.Colors().DefaultBackground,
+		})
 	}
 
 	if ignored {
@@ -377,6 +586,14 @@ func (ps *UI) drawUpgradeableLine(up Package, lNum int, ignored bool) {
 	}
 }
 
+// returns the version for display, or the short commit if the version does not tell the difference
+func versionOrCommit(version, commit, otherVersion string) string {
+	if (version == "" || version == otherVersion) && commit != "" {
+		return shortCommit(commit)
+	}
+	return version
+}
+
 // draw packages on screen
 func (ps *UI) drawPackageListContent(packages []Package, pkgwidth int) {
 	ps.tablePackages.Clear()
@@ -387,13 +604,15 @@ func (ps *UI) drawPackageListContent(packages []Package, pkgwidth int) {
 	// rows
 	for i, pkg := range packages {
 		color := ps.conf.Colors().PackagelistSourceRepository
+		isInstalled := ps.pkgCheckInstalled(pkg)
 
 		if pkg.Remote == "flathub" {
 			color = ps.conf.Colors().PackagelistSourceAUR
 		}
 
 		ps.tablePackages.SetCell(i+1, 0, &tview.TableCell{
-			Text:            pkg.AppID,
+			Text:            pkg.ID,
+			Reference:       pkg.key(),
 			Color:           tcell.ColorWhite,
 			BackgroundColor: ps.conf.Colors().DefaultBackground,
 			MaxWidth:        pkgwidth,
@@ -409,10 +628,21 @@ func (ps *UI) drawPackageListContent(packages []Package, pkgwidth int) {
 				BackgroundColor: ps.conf.Colors().DefaultBackground,
 			}).
 			SetCell(i+1, 3, &tview.TableCell{
+				Text:            pkg.Installation,
+				Color:           color,
+				BackgroundColor: ps.conf.Colors().DefaultBackground,
+			}).
+			SetCell(i+1, 4, &tview.TableCell{
+				Text:            pkg.size(),
+				Color:           tcell.ColorWhite,
+				BackgroundColor: ps.conf.Colors().DefaultBackground,
+				Align:           tview.AlignRight,
+			}).
+			SetCell(i+1, 5, &tview.TableCell{
 				Color:       ps.conf.Colors().DefaultBackground,
-				Text:        ps.getInstalledStateText(pkg.IsInstalled),
+				Text:        ps.getInstalledStateText(isInstalled),
 				Expansion:   1000,
-				Reference:   pkg.IsInstalled,
+				Reference:   isInstalled,
 				Transparent: true,
 			})
 	}
@@ -421,7 +651,7 @@ func (ps *UI) drawPackageListContent(packages []Package, pkgwidth int) {
 
 // adds header row to package table
 func (ps *UI) drawPackageListHeader(pkgwidth int) {
-	columns := []string{"Package", "Version", "Remote", "Installed"}
+	columns := []string{"Package", "Version", "Remote", "Installation", "Size", "Installed"}
 	for i, col := range columns {
 		col := col
 		width := 0
@@ -439,8 +669,10 @@ func (ps *UI) drawPackageListHeader(pkgwidth int) {
 				switch col {
 				case "Package":
 					ps.sortAndRedrawPackageList('N')
-				case "Source":
+				case "Remote":
 					ps.sortAndRedrawPackageList('S')
+				case "Size":
+					ps.sortAndRedrawPackageList('Z')
 				case "Installed":
 					ps.sortAndRedrawPackageList('I')
 				}
@@ -457,41 +689,51 @@ func (ps *UI) sortAndRedrawPackageList(runeKey rune) {
 	case 'N': // sort by name
 		if ps.sortAscending {
 			sort.Slice(ps.shownPackages, func(i, j int) bool {
-				return ps.shownPackages[i].AppID > ps.shownPackages[j].AppID
+				return ps.shownPackages[i].ID > ps.shownPackages[j].ID
 			})
 		} else {
 			sort.Slice(ps.shownPackages, func(i, j int) bool {
-				return ps.shownPackages[j].AppID > ps.shownPackages[i].AppID
+				return ps.shownPackages[j].ID > ps.shownPackages[i].ID
 			})
 		}
 	case 'S': // sort by source
 		if ps.sortAscending {
 			sort.Slice(ps.shownPackages, func(i, j int) bool {
 				if ps.shownPackages[i].Remote == ps.shownPackages[j].Remote {
-					return ps.shownPackages[j].AppID > ps.shownPackages[i].AppID
+					return ps.shownPackages[j].ID > ps.shownPackages[i].ID
 				}
 				return ps.shownPackages[i].Remote > ps.shownPackages[j].Remote
 			})
 		} else {
 			sort.Slice(ps.shownPackages, func(i, j int) bool {
 				if ps.shownPackages[i].Remote == ps.shownPackages[j].Remote {
-					return ps.shownPackages[j].AppID > ps.shownPackages[i].AppID
+					return ps.shownPackages[j].ID > ps.shownPackages[i].ID
 				}
 				return ps.shownPackages[j].Remote > ps.shownPackages[i].Remote
 			})
 		}
+	case 'Z': // sort by size
+		if ps.sortAscending {
+			sort.SliceStable(ps.shownPackages, func(i, j int) bool {
+				return parseSize(ps.shownPackages[i].size()) > parseSize(ps.shownPackages[j].size())
+			})
+		} else {
+			sort.SliceStable(ps.shownPackages, func(i, j int) bool {
+				return parseSize(ps.shownPackages[j].size()) > parseSize(ps.shownPackages[i].size())
+			})
+		}
 	case 'I': // sort by installed state
 		if ps.sortAscending {
 			sort.Slice(ps.shownPackages, func(i, j int) bool {
 				if ps.shownPackages[i].IsInstalled == ps.shownPackages[j].IsInstalled {
-					return ps.shownPackages[j].AppID > ps.shownPackages[i].AppID
+					return ps.shownPackages[j].ID > ps.shownPackages[i].ID
 				}
 				return ps.shownPackages[i].IsInstalled
 			})
 		} else {
 			sort.Slice(ps.shownPackages, func(i, j int) bool {
 				if ps.shownPackages[i].IsInstalled == ps.shownPackages[j].IsInstalled {
-					return ps.shownPackages[j].AppID > ps.shownPackages[i].AppID
+					return ps.shownPackages[j].ID > ps.shownPackages[i].ID
 				}
 				return ps.shownPackages[j].IsInstalled
 			})
@@ -504,18 +746,54 @@ func (ps *UI) sortAndRedrawPackageList(runeKey rune) {
 
 // composes a map with fields and values (package information) for our details box
 func (ps *UI) getDetailFields(pkg Package) (map[string]string, []string) {
-	return map[string]string{
-			"Name":        pkg.Name,
-			"AppID":       pkg.AppID,
-			"Description": pkg.Description,
-			"Version":     pkg.Version,
-			"Branch":      pkg.Branch,
-			"Remote":      pkg.Remote,
-		}, []string{"Name", "AppID",
-			"Description",
-			"Version",
-			"Branch",
-			"Remote"}
+	fields := map[string]string{
+		"Name":           pkg.Name,
+		"Ref":            pkg.Ref.String(),
+		"Description":    pkg.Description,
+		"Version":        pkg.Version,
+		"Commit":         shortCommit(pkg.Commit),
+		"Remote":         pkg.Remote,
+		"Installation":   pkg.Installation,
+		"Installed size": pkg.InstalledSize,
+		"Download size":  pkg.DownloadSize,
+	}
+	order := []string{
+		"Name",
+		"Ref",
+		"Description",
+		"Version",
+		"Commit",
+		"Remote",
+		"Installation",
+		"Installed size",
+		"Download size",
+	}
+
+	// AppStream details
+	if d := pkg.Details; d != nil {
+		fields["Developer"] = d.Developer
+		fields["License"] = d.License
+		fields["Categories"] = strings.Join(d.Categories, ", ")
+		fields["Keywords"] = strings.Join(d.Keywords, ", ")
+		fields["Content rating"] = d.ContentRating
+		fields["Homepage URL"] = d.URLs["homepage"]
+		fields["Bug tracker URL"] = d.URLs["bugtracker"]
+		fields["Help URL"] = d.URLs["help"]
+		fields["Donation URL"] = d.URLs["donation"]
+		fields["Translation URL"] = d.URLs["translate"]
+		order = append(order,
+			"Developer",
+			"License",
+			"Categories",
+			"Keywords",
+			"Content rating",
+			"Homepage URL",
+			"Bug tracker URL",
+			"Help URL",
+			"Donation URL",
+			"Translation URL")
+	}
+	return fields, order
 }
 
 // join and format different dependencies as string
@@ -531,21 +809,23 @@ func (ps *UI) updateInstalledState() {
 	if found {
 		scpkg := cpkg.([]Package)
 		for i := 0; i < len(scpkg); i++ {
-			scpkg[i].IsInstalled = ps.pkgCheckInstalled(scpkg[i].AppID)
+			scpkg[i].IsInstalled = ps.pkgCheckInstalled(scpkg[i])
 		}
 		ps.cacheSearch.Set(sterm, scpkg, time.Until(exp))
 	}
 
 	// update currently shown packages
-	for i := 1; i < ps.tablePackages.GetRowCount(); i++ {
-		isInstalled := ps.pkgCheckInstalled(ps.tablePackages.GetCell(i, 0).Text)
+	for i := 0; i < len(ps.shownPackages) && i+1 < ps.tablePackages.GetRowCount(); i++ {
+		isInstalled := ps.pkgCheckInstalled(ps.shownPackages[i])
+		ps.shownPackages[i].IsInstalled = isInstalled
 		newCell := &tview.TableCell{
+			Color:       ps.conf.Colors().DefaultBackground,
 			Text:        ps.getInstalledStateText(isInstalled),
 			Expansion:   1000,
 			Reference:   isInstalled,
 			Transparent: true,
 		}
-		ps.tablePackages.SetCell(i, 2, newCell)
+		ps.tablePackages.SetCell(i+1, 5, newCell)
 	}
 }
 
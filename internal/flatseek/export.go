@@ -0,0 +1,209 @@
+package flatseek
+
+import (
+	"os"
+	"path"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// returns the file we export to / import from unless another one is entered
+func defaultStateFile() string {
+	home, _ := os.UserHomeDir()
+	return path.Join(home, "flatseek-export.json")
+}
+
+// displays a form to export the installed apps to a file or import them from one
+func (ps *UI) displayExportImport() {
+	form := tview.NewForm().
+		AddInputField("File: ", defaultStateFile(), 50, nil, nil).
+		AddCheckbox("Include overrides: ", false, nil).
+		AddCheckbox("Remove extra apps on import: ", false, nil)
+
+	file := func() string {
+		return strings.TrimSpace(form.GetFormItemByLabel("File: ").(*tview.InputField).GetText())
+	}
+	checked := func(label string) bool {
+		return form.GetFormItemByLabel(label).(*tview.Checkbox).IsChecked()
+	}
+
+	form.AddButton("Export", func() {
+		ps.closeDialogForm()
+		ps.exportState(file(), checked("Include overrides: "))
+	}).
+		AddButton("Import", func() {
+			state, err := loadState(file(), ps.installation)
+			if err != nil {
+				ps.displayMessage(err.Error(), true)
+				return
+			}
+			removeExtra := checked("Remove extra apps on import: ") || state.Prune
+			ps.closeDialogForm()
+			ps.displayStateDiff(state, removeExtra)
+		}).
+		AddButton("Cancel", func() {
+			ps.closeDialogForm()
+		})
+	form.SetTitle(" [::b]" + ps.conf.Glyphs().Settings + "Export / import installed apps ")
+
+	ps.showDialogForm(form)
+}
+
+// exports our remotes and installed apps to a file; flatpak is asked for the overrides of each app
+func (ps *UI) exportState(file string, withOverrides bool) {
+	ps.tableDetails.Clear().
+		SetTitle(" [::b]Exporting installed apps... ")
+
+	go func() {
+		ps.locker.Lock()
+		ps.startSpinner()
+		defer func() {
+			ps.locker.Unlock()
+			ps.stopSpinner()
+		}()
+
+		state, err := exportState(ps.backend, withOverrides)
+		if err == nil {
+			err = saveState(file, state)
+		}
+		ps.app.QueueUpdateDraw(func() {
+			ps.tableDetails.SetTitle("")
+			if err != nil {
+				ps.displayMessage(err.Error(), true)
+				return
+			}
+			ps.displayMessage("Exported remotes and apps to "+file, false)
+		})
+	}()
+}
+
+// compares a state with our machine and displays the differences
+func (ps *UI) displayStateDiff(state State, removeExtra bool) {
+	ps.tableDetails.Clear().
+		SetTitle(" [::b]Comparing with installed apps... ")
+
+	go func() {
+		ps.locker.Lock()
+		ps.startSpinner()
+		defer func() {
+			ps.locker.Unlock()
+			ps.stopSpinner()
+		}()
+
+		diff, err := diffState(ps.backend, state)
+		ps.app.QueueUpdateDraw(func() {
+			if err != nil {
+				ps.tableDetails.SetTitle(" [::b]Error ")
+				ps.displayMessage(err.Error(), true)
+				return
+			}
+			ps.drawStateDiff(diff, removeExtra)
+		})
+	}()
+}
+
+// draws the differences between a state and our machine with a button to apply them
+func (ps *UI) drawStateDiff(diff StateDiff, removeExtra bool) {
+	ps.tableDetails.Clear().
+		SetTitle(" [::b]" + ps.conf.Glyphs().Package + "Import ")
+
+	// remove "Latest news" if they were shown previously
+	if ps.flexRight.GetItemCount() == 2 {
+		ps.flexRight.RemoveItem(ps.flexRight.GetItem(1))
+	}
+
+	r := 0
+	section := func(title, action string, lines [][]string) {
+		if len(lines) == 0 {
+			return
+		}
+		ps.tableDetails.SetCell(r, 0, &tview.TableCell{
+			Text:            "[::b]" + title,
+			Color:           ps.conf.Colors().PackagelistHeader,
+			BackgroundColor: ps.conf.Colors().DefaultBackground,
+		})
+		r++
+		for _, l := range lines {
+			ps.tableDetails.SetCell(r, 0, &tview.TableCell{
+				Text:            action,
+				Color:           ps.conf.Colors().Accent,
+				BackgroundColor: ps.conf.Colors().DefaultBackground,
+			})
+			for i, v := range l {
+				ps.tableDetails.SetCell(r, i+1, &tview.TableCell{
+					Text:            v,
+					Color:           tcell.ColorWhite,
+					BackgroundColor: ps.conf.Colors().DefaultBackground,
+				})
+			}
+			r++
+		}
+		r++
+	}
+
+	remotes := [][]string{}
+	for _, rem := range diff.MissingRemotes {
+		remotes = append(remotes, []string{rem.Name, rem.Installation, rem.URL})
+	}
+	changedRemotes := [][]string{}
+	for _, rem := range diff.ChangedRemotes {
+		changedRemotes = append(changedRemotes, []string{rem.Name, rem.Installation, rem.URL})
+	}
+	apps := [][]string{}
+	for _, app := range diff.MissingApps {
+		apps = append(apps, []string{app.Ref, app.Installation, app.Remote})
+	}
+	extra := [][]string{}
+	for _, pkg := range diff.ExtraApps {
+		extra = append(extra, []string{pkg.Ref.String(), pkg.Installation, pkg.Remote})
+	}
+	overrides := [][]string{}
+	for _, app := range diff.ChangedOverrides {
+		overrides = append(overrides, []string{app.Ref, app.Installation, strings.Join(app.Overrides.args(), " ")})
+	}
+	masks := [][]string{}
+	for _, m := range diff.MissingMasks {
+		masks = append(masks, []string{m.Pattern, m.Installation, m.kind()})
+	}
+	extraMasks := [][]string{}
+	for _, m := range diff.ExtraMasks {
+		extraMasks = append(extraMasks, []string{m.Pattern, m.Installation, m.kind()})
+	}
+	extraAction := "keep"
+	if removeExtra {
+		extraAction = "remove"
+	}
+	section("Missing remotes", "add", remotes)
+	section("Changed remote URLs", "set", changedRemotes)
+	section("Missing apps", "install", apps)
+	section("Changed overrides", "set", overrides)
+	section("Missing masks / pins", "add", masks)
+	section("Extra apps", extraAction, extra)
+	section("Extra masks / pins", extraAction, extraMasks)
+
+	// nothing to do message or apply button
+	if diff.empty() {
+		ps.tableDetails.SetCell(r, 0, &tview.TableCell{
+			Text:            "All remotes and apps are present already",
+			Color:           ps.conf.Colors().PackagelistHeader,
+			BackgroundColor: ps.conf.Colors().DefaultBackground,
+		})
+	} else {
+		ps.tableDetails.SetCell(r, 0, &tview.TableCell{
+			Text:            " [::b]Apply",
+			Align:           tview.AlignCenter,
+			Color:           ps.conf.Colors().SettingsFieldText,
+			BackgroundColor: ps.conf.Colors().SearchBar,
+			Clicked: func() bool {
+				ps.importState(diff, removeExtra)
+				return true
+			},
+		})
+	}
+
+	// set nil to avoid printing package details when resizing
+	ps.selectedPackage = nil
+	ps.tableDetails.ScrollToBeginning()
+}
@@ -0,0 +1,528 @@
+package flatseek
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/XnLogicaL/flatseek/internal/config"
+)
+
+// fakeBackend is an in-memory Backend, populated from fixture files.
+// It allows to run the UI on machines without flatpak.
+type fakeBackend struct {
+	locker       *sync.RWMutex
+	installation string
+
+	remotes   []Remote
+	available []Package
+	installed []Package
+	updates   []Package
+	metadata  map[string]string
+	latest    map[string]string
+	details   map[string]AppDetails
+	manifests map[string]string
+	overrides map[string]Overrides
+	masks     []Mask
+	history   map[string][]Commit
+	instances []Instance
+	lastUsed  map[string]time.Time
+}
+
+// newFakeBackend creates a Backend from the fixture files in a directory:
+// remotes.json, available.json, installed.json, updates.json, metadata.json, latest.json (metadata of updates),
+// details.json, manifests.json, masks.json, history.json, instances.json and lastused.json.
+// Missing files are treated as empty lists.
+func newFakeBackend(dir string) (*fakeBackend, error) {
+	f := &fakeBackend{
+		locker:    &sync.RWMutex{},
+		overrides: map[string]Overrides{},
+	}
+
+	fixtures := map[string]any{
+		"remotes.json":   &f.remotes,
+		"available.json": &f.available,
+		"installed.json": &f.installed,
+		"updates.json":   &f.updates,
+		"metadata.json":  &f.metadata,
+		"latest.json":    &f.latest,
+		"details.json":   &f.details,
+		"manifests.json": &f.manifests,
+		"masks.json":     &f.masks,
+		"history.json":   &f.history,
+		"instances.json": &f.instances,
+		"lastused.json":  &f.lastUsed,
+	}
+	for file, v := range fixtures {
+		b, err := os.ReadFile(path.Join(dir, file))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(b, v); err != nil {
+			return nil, fmt.Errorf("fixture %s: %w", file, err)
+		}
+	}
+
+	for i := range f.installed {
+		f.installed[i].IsInstalled = true
+	}
+	return f, nil
+}
+
+// SetInstallation limits the installed packages, updates and remotes to an installation
+func (f *fakeBackend) SetInstallation(installation string) {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+
+	f.installation = installation
+}
+
+// SetNonInteractive has no effect since our fake operations never ask questions
+func (f *fakeBackend) SetNonInteractive(nonInteractive bool) {}
+
+// returns the packages of our installation
+func (f *fakeBackend) inInstallation(packages []Package) []Package {
+	result := []Package{}
+	for _, pkg := range packages {
+		if f.installation == "" || pkg.Installation == f.installation {
+			result = append(result, pkg)
+		}
+	}
+	return result
+}
+
+// returns the installation an operation on a package applies to: its own, else ours.
+// empty if neither is known, which means any installation
+func (f *fakeBackend) installationOf(pkg Package) string {
+	if pkg.Installation != "" {
+		return pkg.Installation
+	}
+	return f.installation
+}
+
+// Search returns all available packages with the search-term in their name, id or description
+func (f *fakeBackend) Search(term string) ([]Package, error) {
+	f.locker.RLock()
+	defer f.locker.RUnlock()
+
+	term = strings.ToLower(term)
+	packages := []Package{}
+	for _, pkg := range f.available {
+		if strings.Contains(strings.ToLower(pkg.Name), term) ||
+			strings.Contains(strings.ToLower(pkg.ID), term) ||
+			strings.Contains(strings.ToLower(pkg.Description), term) {
+			packages = append(packages, pkg)
+		}
+	}
+	return packages, nil
+}
+
+// Info returns the available package with the same id and branch
+func (f *fakeBackend) Info(pkg Package) (Package, error) {
+	f.locker.RLock()
+	defer f.locker.RUnlock()
+
+	for _, list := range [][]Package{f.installed, f.available} {
+		for _, p := range list {
+			if p.Ref.matches(pkg.Ref) {
+				return p, nil
+			}
+		}
+	}
+	return pkg, fmt.Errorf("%s not found", pkg.Ref)
+}
+
+// Metadata returns the metadata fixture of a package, keyed by its ref.
+// the remote's metadata of a package with an update is taken from the latest fixtures
+func (f *fakeBackend) Metadata(pkg Package) (string, error) {
+	f.locker.RLock()
+	defer f.locker.RUnlock()
+
+	if !pkg.IsInstalled {
+		for r, metadata := range f.latest {
+			if ref, err := ParseRef(r); err == nil && ref.matches(pkg.Ref) {
+				return metadata, nil
+			}
+		}
+	}
+	for r, metadata := range f.metadata {
+		if ref, err := ParseRef(r); err == nil && ref.matches(pkg.Ref) {
+			return metadata, nil
+		}
+	}
+	return "", fmt.Errorf("no metadata for %s", pkg.Ref)
+}
+
+// Manifest returns the manifest fixture of a package, keyed by its ref
+func (f *fakeBackend) Manifest(pkg Package) (string, error) {
+	f.locker.RLock()
+	defer f.locker.RUnlock()
+
+	for r, manifest := range f.manifests {
+		if ref, err := ParseRef(r); err == nil && ref.matches(pkg.Ref) {
+			return manifest, nil
+		}
+	}
+	return "", fmt.Errorf("no manifest for %s", pkg.Ref)
+}
+
+// Details returns the details fixture of a package, keyed by its id
+func (f *fakeBackend) Details(pkg Package) (AppDetails, error) {
+	f.locker.RLock()
+	defer f.locker.RUnlock()
+
+	if details, ok := f.details[pkg.ID]; ok {
+		return details, nil
+	}
+	return AppDetails{}, fmt.Errorf("no AppStream data for %s", pkg.ID)
+}
+
+// Overrides returns the overrides set for an app in its installation
+func (f *fakeBackend) Overrides(pkg Package) (Overrides, error) {
+	f.locker.RLock()
+	defer f.locker.RUnlock()
+
+	return f.overrides[pkg.Installation+"/"+pkg.ID], nil
+}
+
+// SetOverrides replaces the overrides of an app in its installation
+func (f *fakeBackend) SetOverrides(pkg Package, overrides Overrides) error {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+
+	f.overrides[pkg.Installation+"/"+pkg.ID] = overrides
+	return nil
+}
+
+// ListInstalled returns all installed packages
+func (f *fakeBackend) ListInstalled() ([]Package, error) {
+	f.locker.RLock()
+	defer f.locker.RUnlock()
+
+	return f.inInstallation(f.installed), nil
+}
+
+// ListUpdates returns all pending updates
+func (f *fakeBackend) ListUpdates() ([]Package, error) {
+	f.locker.RLock()
+	defer f.locker.RUnlock()
+
+	return f.inInstallation(f.updates), nil
+}
+
+// Install marks a package as installed in its installation; like flatpak we default to the system one
+func (f *fakeBackend) Install(pkg Package) error {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+
+	pkg.Installation = f.installationOf(pkg)
+	if pkg.Installation == "" {
+		pkg.Installation = "system"
+	}
+	for _, p := range f.installed {
+		if p.Ref.matches(pkg.Ref) && p.Installation == pkg.Installation {
+			return fmt.Errorf("%s is already installed", pkg.Ref)
+		}
+	}
+	pkg.IsInstalled = true
+	f.installed = append(f.installed, pkg)
+	return nil
+}
+
+// InstallBundle adds the ref of a bundle to the installed list along with its remote
+func (f *fakeBackend) InstallBundle(bundle Bundle, installation string) error {
+	if bundle.RemoteURL != "" {
+		if err := f.AddRemote(bundle.RemoteName, bundle.RemoteURL, installation); err != nil {
+			return err
+		}
+	}
+	return f.Install(Package{
+		Ref:          bundle.Ref,
+		Name:         bundle.Title,
+		Remote:       bundle.RemoteName,
+		Installation: installation,
+	})
+}
+
+// Uninstall removes a package of its installation from the installed list
+func (f *fakeBackend) Uninstall(pkg Package) error {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+
+	installation := f.installationOf(pkg)
+	for i, p := range f.installed {
+		if p.Ref.matches(pkg.Ref) && (installation == "" || p.Installation == installation) {
+			f.installed = append(f.installed[:i], f.installed[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is not installed", pkg.Ref)
+}
+
+// UninstallAll removes the given packages from the installed list
+func (f *fakeBackend) UninstallAll(pkgs []Package) error {
+	for _, pkg := range pkgs {
+		if err := f.Uninstall(pkg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LastUsed returns the last used fixture of a package, keyed by its ref
+func (f *fakeBackend) LastUsed(pkg Package) (time.Time, error) {
+	f.locker.RLock()
+	defer f.locker.RUnlock()
+
+	for r, t := range f.lastUsed {
+		if ref, err := ParseRef(r); err == nil && ref.matches(pkg.Ref) {
+			return t, nil
+		}
+	}
+	return time.Time{}, nil
+}
+
+// Update replaces the installed packages with their pending update
+func (f *fakeBackend) Update(pkgs []Package) error {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+
+	for _, pkg := range pkgs {
+		installation := f.installationOf(pkg)
+		for i, up := range f.updates {
+			if !up.Ref.matches(pkg.Ref) || (installation != "" && up.Installation != installation) {
+				continue
+			}
+			for j, p := range f.installed {
+				if p.Ref.matches(up.Ref) && p.Installation == up.Installation {
+					f.installed[j].Version = up.Version
+					f.installed[j].Commit = shortCommit(up.Commit)
+				}
+			}
+			f.updates = append(f.updates[:i], f.updates[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// Run checks if an app is installed, nothing is launched
+func (f *fakeBackend) Run(pkg Package, profile config.LaunchProfile) error {
+	f.locker.RLock()
+	defer f.locker.RUnlock()
+
+	for _, p := range f.installed {
+		if p.Ref.matches(pkg.Ref) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is not installed", pkg.Ref)
+}
+
+// History returns the commit log fixture of a package, keyed by its ref
+func (f *fakeBackend) History(pkg Package) ([]Commit, error) {
+	f.locker.RLock()
+	defer f.locker.RUnlock()
+
+	for r, commits := range f.history {
+		if ref, err := ParseRef(r); err == nil && ref.matches(pkg.Ref) {
+			return commits, nil
+		}
+	}
+	return []Commit{}, nil
+}
+
+// Deploy sets the commit and version of an installed package to one of its history
+func (f *fakeBackend) Deploy(pkg Package, commit string) error {
+	commits, _ := f.History(pkg)
+
+	f.locker.Lock()
+	defer f.locker.Unlock()
+
+	for _, c := range commits {
+		if c.ID != commit {
+			continue
+		}
+		for i, p := range f.installed {
+			if p.Ref.matches(pkg.Ref) && p.Installation == pkg.Installation {
+				f.installed[i].Commit = shortCommit(c.ID)
+				f.installed[i].Version = c.Version
+				return nil
+			}
+		}
+		return fmt.Errorf("%s is not installed", pkg.Ref)
+	}
+	return fmt.Errorf("commit %s not found", commit)
+}
+
+// Remotes returns all configured remotes
+func (f *fakeBackend) Remotes() ([]Remote, error) {
+	f.locker.RLock()
+	defer f.locker.RUnlock()
+
+	remotes := []Remote{}
+	for _, r := range f.remotes {
+		if f.installation == "" || r.Installation == f.installation {
+			remotes = append(remotes, r)
+		}
+	}
+	return remotes, nil
+}
+
+// AddRemote adds a remote with the location as URL
+func (f *fakeBackend) AddRemote(name, location, installation string) error {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+
+	for _, r := range f.remotes {
+		if r.Name == name && r.Installation == installation {
+			return nil
+		}
+	}
+	f.remotes = append(f.remotes, Remote{
+		Name:         name,
+		URL:          location,
+		Priority:     1,
+		Installation: installation,
+	})
+	return nil
+}
+
+// RemoveRemote removes a remote and, if forced, the packages installed from it
+func (f *fakeBackend) RemoveRemote(remote Remote, force bool) error {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+
+	installed := []Package{}
+	for _, p := range f.installed {
+		if p.Remote != remote.Name || p.Installation != remote.Installation {
+			installed = append(installed, p)
+		}
+	}
+	if len(installed) != len(f.installed) && !force {
+		return fmt.Errorf("remote %s is still in use", remote.Name)
+	}
+	f.installed = installed
+
+	for i, r := range f.remotes {
+		if r.Name == remote.Name && r.Installation == remote.Installation {
+			f.remotes = append(f.remotes[:i], f.remotes[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("remote %s not found", remote.Name)
+}
+
+// SetRemoteEnabled enables or disables a remote
+func (f *fakeBackend) SetRemoteEnabled(remote Remote, enabled bool) error {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+
+	for i, r := range f.remotes {
+		if r.Name == remote.Name && r.Installation == remote.Installation {
+			f.remotes[i].Disabled = !enabled
+			return nil
+		}
+	}
+	return fmt.Errorf("remote %s not found", remote.Name)
+}
+
+// SetRemoteURL changes the URL of a remote
+func (f *fakeBackend) SetRemoteURL(remote Remote, url string) error {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+
+	for i, r := range f.remotes {
+		if r.Name == remote.Name && r.Installation == remote.Installation {
+			f.remotes[i].URL = url
+			return nil
+		}
+	}
+	return fmt.Errorf("remote %s not found", remote.Name)
+}
+
+// UpdateAppstream sets the AppStream update time of a remote to now
+func (f *fakeBackend) UpdateAppstream(remote Remote) error {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+
+	for i, r := range f.remotes {
+		if r.Name == remote.Name && r.Installation == remote.Installation {
+			f.remotes[i].AppstreamUpdated = time.Now()
+			return nil
+		}
+	}
+	return fmt.Errorf("remote %s not found", remote.Name)
+}
+
+// Masks returns the masks and pins of our installation
+func (f *fakeBackend) Masks() ([]Mask, error) {
+	f.locker.RLock()
+	defer f.locker.RUnlock()
+
+	masks := []Mask{}
+	for _, m := range f.masks {
+		if f.installation == "" || m.Installation == f.installation {
+			masks = append(masks, m)
+		}
+	}
+	return masks, nil
+}
+
+// AddMask adds a mask or pin unless it exists already
+func (f *fakeBackend) AddMask(mask Mask) error {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+
+	for _, m := range f.masks {
+		if m == mask {
+			return nil
+		}
+	}
+	f.masks = append(f.masks, mask)
+	return nil
+}
+
+// RemoveMask removes a mask or pin
+func (f *fakeBackend) RemoveMask(mask Mask) error {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+
+	for i, m := range f.masks {
+		if m == mask {
+			f.masks = append(f.masks[:i], f.masks[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("%s %s not found", mask.kind(), mask.Pattern)
+}
+
+// Instances returns the instances fixture
+func (f *fakeBackend) Instances() ([]Instance, error) {
+	f.locker.RLock()
+	defer f.locker.RUnlock()
+
+	return append([]Instance{}, f.instances...), nil
+}
+
+// Kill removes an instance
+func (f *fakeBackend) Kill(instance Instance) error {
+	f.locker.Lock()
+	defer f.locker.Unlock()
+
+	for i, inst := range f.instances {
+		if inst.ID == instance.ID {
+			f.instances = append(f.instances[:i], f.instances[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("instance %s not found", instance.ID)
+}
@@ -0,0 +1,103 @@
+package flatseek
+
+import (
+	"slices"
+	"testing"
+)
+
+// creates a fake backend from our fixtures
+func newTestBackend(t *testing.T) *fakeBackend {
+	t.Helper()
+	f, err := newFakeBackend("../../testdata/fixtures")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+// returns the installations a ref is installed in
+func installedIn(t *testing.T, b Backend, ref string) []string {
+	t.Helper()
+	r, err := ParseRef(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	installed, err := b.ListInstalled()
+	if err != nil {
+		t.Fatal(err)
+	}
+	installations := []string{}
+	for _, pkg := range installed {
+		if pkg.Ref.matches(r) {
+			installations = append(installations, pkg.Installation)
+		}
+	}
+	return installations
+}
+
+func TestFakeInstallation(t *testing.T) {
+	calculator, _ := ParseRef("app/org.gnome.Calculator/x86_64/stable")
+	firefox, _ := ParseRef("app/org.mozilla.firefox/x86_64/stable")
+
+	tests := []struct {
+		name         string
+		installation string // of the backend
+		op           func(b Backend) error
+		ref          string
+		want         []string
+		wantErr      bool
+	}{
+		{
+			name: "install in another installation",
+			op:   func(b Backend) error { return b.Install(Package{Ref: calculator, Installation: "user"}) },
+			ref:  "app/org.gnome.Calculator/x86_64/stable",
+			want: []string{"system", "user"},
+		},
+		{
+			name:    "install twice",
+			op:      func(b Backend) error { return b.Install(Package{Ref: calculator, Installation: "system"}) },
+			ref:     "app/org.gnome.Calculator/x86_64/stable",
+			want:    []string{"system"},
+			wantErr: true,
+		},
+		{
+			name: "install defaults to system",
+			op:   func(b Backend) error { return b.Install(Package{Ref: firefox}) },
+			ref:  "app/org.mozilla.firefox/x86_64/stable",
+			want: []string{"system"},
+		},
+		{
+			name:         "install in our installation",
+			installation: "user",
+			op:           func(b Backend) error { return b.Install(Package{Ref: firefox}) },
+			ref:          "app/org.mozilla.firefox/x86_64/stable",
+			want:         []string{"user"},
+		},
+		{
+			name:    "uninstall from another installation",
+			op:      func(b Backend) error { return b.Uninstall(Package{Ref: calculator, Installation: "user"}) },
+			ref:     "app/org.gnome.Calculator/x86_64/stable",
+			want:    []string{"system"},
+			wantErr: true,
+		},
+		{
+			name: "uninstall from its installation",
+			op:   func(b Backend) error { return b.Uninstall(Package{Ref: calculator, Installation: "system"}) },
+			ref:  "app/org.gnome.Calculator/x86_64/stable",
+			want: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTestBackend(t)
+			f.SetInstallation(tt.installation)
+			if err := tt.op(f); (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			f.SetInstallation("")
+			if got := installedIn(t, f, tt.ref); !slices.Equal(got, tt.want) {
+				t.Errorf("installed in %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,62 +1,574 @@
 package flatseek
 
 import (
-	"context"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"path"
+	"sort"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
+
+	"github.com/XnLogicaL/flatseek/internal/config"
+	"github.com/XnLogicaL/flatseek/internal/util"
 )
 
-func flatpakQuery(output string) []Package {
-	lines := strings.Split(output, "\n")
-	result := []Package{}
+// cliBackend implements our Backend by running the flatpak command line tool
+type cliBackend struct {
+	conf           *config.Settings
+	appstream      *appstreamIndex
+	installation   string
+	nonInteractive bool
+}
+
+// newCLIBackend creates a Backend talking to flatpak
+func newCLIBackend(conf *config.Settings) *cliBackend {
+	return &cliBackend{
+		conf:      conf,
+		appstream: newAppstreamIndex(),
+	}
+}
+
+// SetInstallation limits our operations to an installation, all installations are used if it is empty
+func (b *cliBackend) SetInstallation(installation string) {
+	b.installation = installation
+}
 
-	if len(lines) < 2 {
-		return result
+// returns the installations our operations are limited to
+func (b *cliBackend) installations() []Installation {
+	installations := []Installation{}
+	for _, inst := range listInstallations() {
+		if b.installation == "" || b.installation == inst.ID {
+			installations = append(installations, inst)
+		}
+	}
+	return installations
+}
+
+// runs flatpak with the given arguments and returns its output
+func (b *cliBackend) flatpak(args ...string) (string, error) {
+	out, err := exec.Command("flatpak", args...).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("flatpak %s failed: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", fmt.Errorf("flatpak %s failed: %w", args[0], err)
+	}
+	return string(out), nil
+}
+
+// splits tab separated flatpak output into its columns
+func flatpakColumns(output string, numColumns int) [][]string {
+	result := [][]string{}
+	for _, line := range strings.Split(output, "\n") {
+		parts := strings.Split(strings.TrimRight(line, "\r"), "\t")
+		if len(parts) < numColumns {
+			continue
+		}
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+		result = append(result, parts)
 	}
+	return result
+}
 
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		parts := strings.Split(line, "\t")
-		if len(parts) < 5 {
+// parses "Key: Value" formatted output of flatpak info / remote-info
+func flatpakKeyValues(output string) map[string]string {
+	result := map[string]string{}
+	for _, line := range strings.Split(output, "\n") {
+		k, v, found := strings.Cut(line, ":")
+		if !found {
 			continue
 		}
+		k = strings.TrimSpace(k)
+		if k == "" || strings.Contains(k, " ") {
+			continue
+		}
+		result[k] = strings.TrimSpace(v)
+	}
+	return result
+}
 
-		result = append(result, Package{
-			Name:        parts[0],
-			Description: parts[1],
-			AppID:       parts[2],
-			Version:     parts[3],
-			Branch:      parts[4],
-			Remote:      parts[5],
+// Search returns all packages on our remotes matching a search-term.
+// The local AppStream data of our remotes is searched, flatpak search is only used if there is none.
+// The packages found are returned along with an error if the data of some remotes was unreadable
+func (b *cliBackend) Search(term string) ([]Package, error) {
+	packages, available, err := b.appstream.search(term, b.conf.SearchMode, b.conf.SearchBy, b.installation)
+	if available {
+		return packages, err
+	}
+
+	args := []string{"search", "--columns=name,description,application,version,branch,remotes", term}
+	args = append(args, installationFlag(b.installation)...)
+	out, err := b.flatpak(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	packages = []Package{}
+	for _, cols := range flatpakColumns(out, 6) {
+		packages = append(packages, Package{
+			Ref: Ref{
+				ID:     cols[2],
+				Branch: cols[4],
+			},
+			Name:         cols[0],
+			Description:  cols[1],
+			Version:      cols[3],
+			Remote:       strings.Split(cols[5], ",")[0],
+			Installation: b.installation,
 		})
 	}
+	return packages, nil
+}
 
-	return result
+// Info returns detailed information for a package, from its installation if installed, otherwise from its remote
+func (b *cliBackend) Info(pkg Package) (Package, error) {
+	args := []string{"remote-info", pkg.Remote, pkg.Ref.String()}
+	if pkg.IsInstalled {
+		args = []string{"info", pkg.Ref.String()}
+	}
+	args = append(args, installationFlag(pkg.Installation)...)
+	out, err := b.flatpak(args...)
+	if err != nil {
+		return pkg, err
+	}
+
+	kv := flatpakKeyValues(out)
+	if ref, err := ParseRef(kv["Ref"]); err == nil {
+		pkg.Ref = ref
+	}
+	if v, ok := kv["Version"]; ok {
+		pkg.Version = v
+	}
+	if v, ok := kv["Commit"]; ok {
+		pkg.Commit = v
+	}
+	if v, ok := kv["Installed"]; ok {
+		pkg.InstalledSize = v
+	}
+	if v, ok := kv["Download"]; ok {
+		pkg.DownloadSize = v
+	}
+	if v, ok := kv["Origin"]; ok {
+		pkg.Remote = v
+	}
+	if v, ok := kv["Installation"]; ok {
+		pkg.Installation = v
+	}
+	if v, ok := kv["End-of-life"]; ok {
+		pkg.EOL = v
+	}
+	return pkg, nil
+}
+
+// Manifest returns the manifest.json shipped in the deploy directory of an installed app
+func (b *cliBackend) Manifest(pkg Package) (string, error) {
+	out, err := b.flatpak(append([]string{"info", "--show-location", pkg.Ref.String()}, installationFlag(pkg.Installation)...)...)
+	if err != nil {
+		return "", err
+	}
+	manifest, err := os.ReadFile(path.Join(strings.TrimSpace(out), "files", "manifest.json"))
+	if err != nil {
+		return "", err
+	}
+	return string(manifest), nil
+}
+
+// Details returns the AppStream information of a package from the appstream data of its remote
+func (b *cliBackend) Details(pkg Package) (AppDetails, error) {
+	details, found, err := b.appstream.details(pkg)
+	if err != nil {
+		return details, err
+	}
+	if !found {
+		return details, fmt.Errorf("no AppStream data for %s", pkg.ID)
+	}
+	return details, nil
+}
+
+// Metadata returns the metadata of a package, from its installation if installed, otherwise from its remote
+func (b *cliBackend) Metadata(pkg Package) (string, error) {
+	args := []string{"remote-info", "--show-metadata", pkg.Remote, pkg.Ref.String()}
+	if pkg.IsInstalled {
+		args = []string{"info", "--show-metadata", pkg.Ref.String()}
+	}
+	args = append(args, installationFlag(pkg.Installation)...)
+	return b.flatpak(args...)
+}
+
+// Overrides returns the permission overrides of an app in its installation
+func (b *cliBackend) Overrides(pkg Package) (Overrides, error) {
+	out, err := b.flatpak(append([]string{"override", "--show", pkg.ID}, installationFlag(pkg.Installation)...)...)
+	if err != nil {
+		return Overrides{}, err
+	}
+	return parseOverrides(out), nil
+}
+
+// SetOverrides resets the overrides of an app and applies the given ones; it is attached to the terminal
+func (b *cliBackend) SetOverrides(pkg Package, overrides Overrides) error {
+	if err := runAttached("flatpak", append([]string{"override", "--reset", pkg.ID}, installationFlag(pkg.Installation)...)...); err != nil {
+		return err
+	}
+	args := overrides.args()
+	if len(args) == 0 {
+		return nil
+	}
+	args = append(append([]string{"override"}, installationFlag(pkg.Installation)...), args...)
+	return runAttached("flatpak", append(args, pkg.ID)...)
+}
+
+// ListInstalled returns all installed apps and runtimes of our installations
+func (b *cliBackend) ListInstalled() ([]Package, error) {
+	packages := []Package{}
+	for _, inst := range b.installations() {
+		for _, kind := range []string{"app", "runtime"} {
+			out, err := b.flatpak(append([]string{"list", "--" + kind,
+				"--columns=name,description,application,version,branch,arch,origin,size,active"}, installationFlag(inst.ID)...)...)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, cols := range flatpakColumns(out, 9) {
+				packages = append(packages, Package{
+					Ref: Ref{
+						Kind:   kind,
+						ID:     cols[2],
+						Arch:   cols[5],
+						Branch: cols[4],
+					},
+					Name:          cols[0],
+					Description:   cols[1],
+					Version:       cols[3],
+					Remote:        cols[6],
+					Installation:  inst.ID,
+					InstalledSize: cols[7],
+					Commit:        cols[8],
+					IsInstalled:   true,
+				})
+			}
+		}
+	}
+	return packages, nil
+}
+
+// ListUpdates returns all installed apps and runtimes of our installations that can be updated
+func (b *cliBackend) ListUpdates() ([]Package, error) {
+	packages := []Package{}
+	for _, inst := range b.installations() {
+		for _, kind := range []string{"app", "runtime"} {
+			out, err := b.flatpak(append([]string{"remote-ls", "--" + kind, "--updates",
+				"--columns=name,description,application,version,branch,arch,origin,commit,installed-size,download-size"}, installationFlag(inst.ID)...)...)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, cols := range flatpakColumns(out, 10) {
+				packages = append(packages, Package{
+					Ref: Ref{
+						Kind:   kind,
+						ID:     cols[2],
+						Arch:   cols[5],
+						Branch: cols[4],
+					},
+					Name:          cols[0],
+					Description:   cols[1],
+					Version:       cols[3],
+					Remote:        cols[6],
+					Commit:        cols[7],
+					InstalledSize: cols[8],
+					DownloadSize:  cols[9],
+					Installation:  inst.ID,
+					IsInstalled:   true,
+				})
+			}
+		}
+	}
+	return packages, nil
+}
+
+// SetNonInteractive makes our install and uninstall commands pass --noninteractive to flatpak
+func (b *cliBackend) SetNonInteractive(nonInteractive bool) {
+	b.nonInteractive = nonInteractive
+}
+
+// Install installs a package with our configured install command; it is attached to the terminal
+func (b *cliBackend) Install(pkg Package) error {
+	return runAttached(util.Shell(), "-c", b.commandForPackage(b.conf.InstallCommand, pkg))
 }
 
-func (ps *UI) pkgSearch(term string) ([]Package, []Package, error) {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+// Uninstall removes a package with our configured uninstall command; it is attached to the terminal
+func (b *cliBackend) Uninstall(pkg Package) error {
+	return runAttached(util.Shell(), "-c", b.commandForPackage(b.conf.UninstallCommand, pkg))
+}
 
-	cmd := exec.CommandContext(ctx, "flatpak", "search", "--columns=all", term)
+// UninstallAll removes the given packages, grouped by installation; flatpak is attached to the terminal
+func (b *cliBackend) UninstallAll(pkgs []Package) error {
+	refs := map[string][]string{}
+	installations := []string{}
+	for _, pkg := range pkgs {
+		if _, ok := refs[pkg.Installation]; !ok {
+			installations = append(installations, pkg.Installation)
+		}
+		refs[pkg.Installation] = append(refs[pkg.Installation], pkg.Ref.String())
+	}
 
-	out, err := cmd.Output()
+	for _, installation := range installations {
+		args := append([]string{"uninstall"}, installationFlag(installation)...)
+		if b.nonInteractive {
+			args = append(args, "--noninteractive")
+		}
+		if err := runAttached("flatpak", append(args, refs[installation]...)...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LastUsed returns the access time of the ld.so.cache in the deploy directory of a package, which is read whenever
+// an app starts with it, or of the deploy directory itself for extensions. it is unknown on noatime mounts
+func (b *cliBackend) LastUsed(pkg Package) (time.Time, error) {
+	out, err := b.flatpak(append([]string{"info", "--show-location", pkg.Ref.String()}, installationFlag(pkg.Installation)...)...)
 	if err != nil {
-		return nil, nil, fmt.Errorf("flatpak search failed: %w", err)
+		return time.Time{}, err
+	}
+	dir := path.Join(strings.TrimSpace(out), "files")
+	for _, file := range []string{path.Join(dir, "etc", "ld.so.cache"), dir} {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		if st, ok := info.Sys().(*syscall.Stat_t); ok && st.Atim.Sec > 0 {
+			return time.Unix(st.Atim.Unix()), nil
+		}
+	}
+	return time.Time{}, nil
+}
+
+// InstallBundle installs from a .flatpak or .flatpakref file; flatpak is attached to the terminal
+func (b *cliBackend) InstallBundle(bundle Bundle, installation string) error {
+	from := "--from"
+	if bundle.Kind == "bundle" {
+		from = "--bundle"
+	}
+	args := append([]string{"install", from, bundle.File}, installationFlag(installation)...)
+	if b.nonInteractive {
+		args = append(args, "--noninteractive")
 	}
+	return runAttached("flatpak", args...)
+}
 
-	packages := flatpakQuery(string(out))
-	installed := []Package{}
+// fills in the placeholders {installation}, {remote} and {ref} of a command.
+// if there are none for the remote and ref, the ref is appended to the command
+func (b *cliBackend) commandForPackage(command string, pkg Package) string {
+	installation := pkg.Installation
+	if installation == "" {
+		installation = b.installation
+	}
+	if !strings.Contains(command, "{ref}") && !strings.Contains(command, "{remote}") {
+		command += " {ref}"
+	}
+	if b.nonInteractive {
+		command += " --noninteractive"
+	}
+	return strings.NewReplacer("{installation}", strings.Join(installationFlag(installation), " "),
+		"{remote}", pkg.Remote,
+		"{ref}", pkg.Ref.String()).Replace(command)
+}
 
-	return packages, installed, nil
+// Update updates the given packages, grouped by installation, with our configured upgrade command
+// and their refs appended; it is attached to the terminal
+func (b *cliBackend) Update(pkgs []Package) error {
+	refs := map[string][]string{}
+	installations := []string{}
+	for _, pkg := range pkgs {
+		if _, ok := refs[pkg.Installation]; !ok {
+			installations = append(installations, pkg.Installation)
+		}
+		refs[pkg.Installation] = append(refs[pkg.Installation], pkg.Ref.String())
+	}
+
+	for _, installation := range installations {
+		command := strings.ReplaceAll(b.conf.SysUpgradeCommand, "{installation}", strings.Join(installationFlag(installation), " "))
+		if b.nonInteractive {
+			command += " --noninteractive"
+		}
+		if err := runAttached(util.Shell(), "-c", command+" "+strings.Join(refs[installation], " ")); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// checks the local db if a package is installed
-func (ps *UI) pkgCheckInstalled(pkg string) bool {
-	return false
+// Run launches an app. A shell into the sandbox is attached to the terminal,
+// apps are started in their own session so they keep running when we exit
+func (b *cliBackend) Run(pkg Package, profile config.LaunchProfile) error {
+	if profile.Shell {
+		return runAttached("flatpak", launchArgs(pkg, profile)...)
+	}
+	cmd := exec.Command("flatpak", launchArgs(pkg, profile)...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
+
+// History returns the commit log of a package on its remote
+func (b *cliBackend) History(pkg Package) ([]Commit, error) {
+	args := []string{"remote-info", "--log", pkg.Remote, pkg.Ref.String()}
+	args = append(args, installationFlag(pkg.Installation)...)
+	out, err := b.flatpak(args...)
+	if err != nil {
+		return nil, err
+	}
+	return parseCommitLog(out), nil
+}
+
+// Deploy updates or downgrades a package to a specific commit; it is attached to the terminal
+func (b *cliBackend) Deploy(pkg Package, commit string) error {
+	args := []string{"update", "--commit=" + commit, pkg.Ref.String()}
+	args = append(args, installationFlag(pkg.Installation)...)
+	return runAttached("flatpak", args...)
+}
+
+// Remotes returns all remotes of our installations including disabled ones
+func (b *cliBackend) Remotes() ([]Remote, error) {
+	remotes := []Remote{}
+	for _, inst := range b.installations() {
+		out, err := b.flatpak(append([]string{"remotes", "--show-disabled", "--columns=name,title,url,priority,filter,options"}, installationFlag(inst.ID)...)...)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, cols := range flatpakColumns(out, 6) {
+			prio, _ := strconv.Atoi(cols[3])
+			remote := Remote{
+				Name:         cols[0],
+				Title:        cols[1],
+				URL:          cols[2],
+				Priority:     prio,
+				Filter:       cols[4],
+				Installation: inst.ID,
+				Disabled:     strings.Contains(cols[5], "disabled"),
+			}
+			if fi, err := os.Stat(path.Join(inst.Path, "appstream", remote.Name, defaultArch(), "active", "appstream.xml.gz")); err == nil {
+				remote.AppstreamUpdated = fi.ModTime()
+			}
+			remotes = append(remotes, remote)
+		}
+	}
+	sort.Slice(remotes, func(i, j int) bool {
+		if remotes[i].Installation == remotes[j].Installation {
+			return remotes[i].Name < remotes[j].Name
+		}
+		return remotes[i].Installation < remotes[j].Installation
+	})
+	return remotes, nil
+}
+
+// AddRemote adds a remote from a repository URL or .flatpakrepo file; flatpak is attached to the terminal
+func (b *cliBackend) AddRemote(name, location, installation string) error {
+	return runAttached("flatpak", append([]string{"remote-add", "--if-not-exists", name, location}, installationFlag(installation)...)...)
+}
+
+// RemoveRemote removes a remote; flatpak is attached to the terminal
+func (b *cliBackend) RemoveRemote(remote Remote, force bool) error {
+	args := append([]string{"remote-delete", remote.Name}, installationFlag(remote.Installation)...)
+	if force {
+		args = append(args, "--force")
+	}
+	return runAttached("flatpak", args...)
+}
+
+// SetRemoteEnabled enables or disables a remote; flatpak is attached to the terminal
+func (b *cliBackend) SetRemoteEnabled(remote Remote, enabled bool) error {
+	flag := "--disable"
+	if enabled {
+		flag = "--enable"
+	}
+	return runAttached("flatpak", append([]string{"remote-modify", flag, remote.Name}, installationFlag(remote.Installation)...)...)
+}
+
+// SetRemoteURL changes the URL of a remote; flatpak is attached to the terminal
+func (b *cliBackend) SetRemoteURL(remote Remote, url string) error {
+	return runAttached("flatpak", append([]string{"remote-modify", "--url=" + url, remote.Name}, installationFlag(remote.Installation)...)...)
+}
+
+// UpdateAppstream downloads the latest AppStream data of a remote; flatpak is attached to the terminal
+func (b *cliBackend) UpdateAppstream(remote Remote) error {
+	return runAttached("flatpak", append([]string{"update", "--appstream", remote.Name}, installationFlag(remote.Installation)...)...)
+}
+
+// Masks returns the masked and pinned patterns of our installations
+func (b *cliBackend) Masks() ([]Mask, error) {
+	masks := []Mask{}
+	for _, inst := range b.installations() {
+		for _, command := range []string{"mask", "pin"} {
+			out, err := b.flatpak(append([]string{command}, installationFlag(inst.ID)...)...)
+			if err != nil {
+				return nil, err
+			}
+			for _, line := range strings.Split(out, "\n") {
+				line = strings.TrimSpace(line)
+				// skip headings and "No masked / pinned patterns"
+				if line == "" || strings.HasSuffix(line, ":") || strings.HasPrefix(line, "No ") {
+					continue
+				}
+				masks = append(masks, Mask{
+					Pattern:      line,
+					Installation: inst.ID,
+					Pin:          command == "pin",
+				})
+			}
+		}
+	}
+	return masks, nil
+}
+
+// AddMask masks or pins a pattern; it is attached to the terminal
+func (b *cliBackend) AddMask(mask Mask) error {
+	return runAttached("flatpak", append([]string{mask.kind(), mask.Pattern}, installationFlag(mask.Installation)...)...)
+}
+
+// RemoveMask removes a mask or pin; it is attached to the terminal
+func (b *cliBackend) RemoveMask(mask Mask) error {
+	return runAttached("flatpak", append([]string{mask.kind(), "--remove", mask.Pattern}, installationFlag(mask.Installation)...)...)
+}
+
+// Instances returns the running instances of apps
+func (b *cliBackend) Instances() ([]Instance, error) {
+	out, err := b.flatpak("ps", "--columns=instance,pid,child-pid,application,arch,branch,runtime")
+	if err != nil {
+		return nil, err
+	}
+
+	instances := []Instance{}
+	for _, cols := range flatpakColumns(out, 7) {
+		pid, _ := strconv.Atoi(cols[1])
+		childPID, _ := strconv.Atoi(cols[2])
+		instances = append(instances, Instance{
+			ID:       cols[0],
+			PID:      pid,
+			ChildPID: childPID,
+			App:      cols[3],
+			Arch:     cols[4],
+			Branch:   cols[5],
+			Runtime:  cols[6],
+		})
+	}
+	return instances, nil
 }
 
-func (ps *UI) pkgGetSuggestion(text string) string {
-	return "dihh"
+// Kill stops a running instance
+func (b *cliBackend) Kill(instance Instance) error {
+	_, err := b.flatpak("kill", instance.ID)
+	return err
 }
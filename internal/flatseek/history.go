@@ -0,0 +1,175 @@
+package flatseek
+
+import (
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// Commit is an entry of the commit log of a ref on its remote
+type Commit struct {
+	ID      string
+	Date    string
+	Subject string
+	Version string
+}
+
+// parses the output of "flatpak remote-info --log"; the latest commit comes first.
+// its version is only printed in the header before it, the older commits have none
+func parseCommitLog(output string) []Commit {
+	commits := []Commit{}
+	version := ""
+	for _, line := range strings.Split(output, "\n") {
+		k, v, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+		if k == "Commit" {
+			commits = append(commits, Commit{ID: v})
+			continue
+		}
+		if len(commits) == 0 {
+			if k == "Version" {
+				version = v
+			}
+			continue
+		}
+		c := &commits[len(commits)-1]
+		switch k {
+		case "Subject":
+			c.Subject = v
+		case "Date":
+			c.Date = v
+		case "Version":
+			c.Version = v
+		}
+	}
+	if len(commits) > 0 && commits[0].Version == "" {
+		commits[0].Version = version
+	}
+	return commits
+}
+
+// displays the commit history of an installed package
+func (ps *UI) displayHistory(pkg Package) {
+	ps.tableDetails.Clear().
+		SetTitle(" [::b]Retrieving history of " + pkg.ID + "... ")
+
+	go func() {
+		ps.locker.Lock()
+		ps.startSpinner()
+		defer func() {
+			ps.locker.Unlock()
+			ps.stopSpinner()
+		}()
+
+		// the deployed commit may have changed
+		err := ps.refreshInstalledRefs()
+		var commits []Commit
+		if err == nil {
+			if ipkg, found := ps.getInstalledRef(pkg); found {
+				pkg.Commit = ipkg.Commit
+				pkg.Installation = ipkg.Installation
+			}
+			commits, err = ps.backend.History(pkg)
+		}
+		ps.app.QueueUpdateDraw(func() {
+			if err != nil {
+				ps.tableDetails.SetTitle(" [::b]Error ")
+				ps.displayMessage(err.Error(), true)
+				return
+			}
+			ps.drawHistory(pkg, commits)
+		})
+	}()
+}
+
+// draws the commit history of a package with a button to deploy each commit
+func (ps *UI) drawHistory(pkg Package, commits []Commit) {
+	ps.tableDetails.Clear().
+		SetTitle(" [::b]" + ps.conf.Glyphs().Package + "History of " + pkg.ID + " ")
+
+	// header
+	columns := []string{"Commit  ", "Date  ", "Version  ", "Subject  ", ""}
+	for i, col := range columns {
+		ps.tableDetails.SetCell(0, i, &tview.TableCell{
+			Text:            col,
+			Color:           ps.conf.Colors().PackagelistHeader,
+			BackgroundColor: ps.conf.Colors().DefaultBackground,
+		})
+	}
+
+	// lines
+	r := 2
+	for _, c := range commits {
+		c := c
+		ps.tableDetails.SetCell(r, 0, &tview.TableCell{
+			Text:            "[::b]" + shortCommit(c.ID),
+			Color:           ps.conf.Colors().Accent,
+			BackgroundColor: ps.conf.Colors().DefaultBackground,
+		}).
+			SetCell(r, 1, &tview.TableCell{
+				Text:            c.Date,
+				Color:           tcell.ColorWhite,
+				BackgroundColor: ps.conf.Colors().DefaultBackground,
+			}).
+			SetCell(r, 2, &tview.TableCell{
+				Text:            c.Version,
+				Color:           ps.conf.Colors().PackagelistSourceRepository,
+				BackgroundColor: ps.conf.Colors().DefaultBackground,
+			}).
+			SetCell(r, 3, &tview.TableCell{
+				Text:            c.Subject,
+				Color:           tcell.ColorWhite,
+				BackgroundColor: ps.conf.Colors().DefaultBackground,
+			})
+
+		if sameCommit(c.ID, pkg.Commit) {
+			ps.tableDetails.SetCell(r, 4, &tview.TableCell{
+				Text:            "[::b]deployed",
+				Color:           ps.conf.Colors().PackagelistHeader,
+				BackgroundColor: ps.conf.Colors().DefaultBackground,
+			})
+		} else {
+			ps.tableDetails.SetCell(r, 4, &tview.TableCell{
+				Text:            " [::b]Deploy",
+				Color:           ps.conf.Colors().SettingsFieldText,
+				BackgroundColor: ps.conf.Colors().SearchBar,
+				Clicked: func() bool {
+					ps.deployCommit(pkg, c.ID)
+					ps.displayHistory(pkg)
+					return true
+				},
+			})
+		}
+		r++
+	}
+
+	// no history message and back button
+	r++
+	if len(commits) == 0 {
+		ps.tableDetails.SetCell(r, 0, &tview.TableCell{
+			Text:            "No commits found",
+			Color:           ps.conf.Colors().PackagelistHeader,
+			BackgroundColor: ps.conf.Colors().DefaultBackground,
+		})
+		r += 2
+	}
+	ps.tableDetails.SetCell(r, 0, &tview.TableCell{
+		Text:            " [::b]Back",
+		Align:           tview.AlignCenter,
+		Color:           ps.conf.Colors().SettingsFieldText,
+		BackgroundColor: ps.conf.Colors().SearchBar,
+		Clicked: func() bool {
+			ps.selectedPackage = &pkg
+			ps.drawPackageInfo(pkg, ps.width)
+			return true
+		},
+	})
+
+	// set nil to avoid printing package details when resizing
+	ps.selectedPackage = nil
+	ps.tableDetails.ScrollToBeginning()
+}
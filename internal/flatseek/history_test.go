@@ -0,0 +1,78 @@
+package flatseek
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseCommitLog(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []Commit
+	}{
+		{
+			name:   "empty",
+			output: "",
+			want:   []Commit{},
+		},
+		{
+			name: "version of the latest commit in the header",
+			output: `
+GNOME Calculator - Perform arithmetic, scientific or financial calculations
+
+        ID: org.gnome.Calculator
+       Ref: app/org.gnome.Calculator/x86_64/stable
+      Arch: x86_64
+    Branch: stable
+   Version: 46.1
+   License: GPL-3.0+
+Collection: org.flathub.Stable
+  Download: 1.4 MB
+ Installed: 5.2 MB
+   Runtime: org.gnome.Platform/x86_64/46
+       Sdk: org.gnome.Sdk/x86_64/46
+
+    Commit: c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d3e4f5a6b7c8d9e0f1a2b3c4d5
+    Parent: 3f1c2a9d8e7b6c5a4f3e2d1c0b9a8f7e6d5c4b3a2f1e0d9c8b7a6f5e4d3c2b1a
+   Subject: Update to 46.1 (8d2e1f0a)
+      Date: 2026-10-01 12:00:00 +0000
+   History:
+
+    Commit: 3f1c2a9d8e7b6c5a4f3e2d1c0b9a8f7e6d5c4b3a2f1e0d9c8b7a6f5e4d3c2b1a
+   Subject: Update to 46.0 (5b7c9a2e)
+      Date: 2026-09-01 08:30:00 +0000
+
+    Commit: 7e6d5c4b3a2f1e0d9c8b7a6f5e4d3c2b1a3f1c2a9d8e7b6c5a4f3e2d1c0b9a8f
+   Subject: Update to 45.0.2 (1a2b3c4d)
+      Date: 2026-05-12 17:04:11 +0000
+`,
+			want: []Commit{
+				{ID: "c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d3e4f5a6b7c8d9e0f1a2b3c4d5", Subject: "Update to 46.1 (8d2e1f0a)", Date: "2026-10-01 12:00:00 +0000", Version: "46.1"},
+				{ID: "3f1c2a9d8e7b6c5a4f3e2d1c0b9a8f7e6d5c4b3a2f1e0d9c8b7a6f5e4d3c2b1a", Subject: "Update to 46.0 (5b7c9a2e)", Date: "2026-09-01 08:30:00 +0000"},
+				{ID: "7e6d5c4b3a2f1e0d9c8b7a6f5e4d3c2b1a3f1c2a9d8e7b6c5a4f3e2d1c0b9a8f", Subject: "Update to 45.0.2 (1a2b3c4d)", Date: "2026-05-12 17:04:11 +0000"},
+			},
+		},
+		{
+			name: "without version",
+			output: `        ID: org.example.App
+       Ref: app/org.example.App/x86_64/master
+
+    Commit: 9a8b7c6d5e4f3a2b1c0d9e8f7a6b5c4d3e2f1a0b9c8d7e6f5a4b3c2d1e0f9a8b
+   Subject: Export org.example.App
+      Date: 2026-01-02 03:04:05 +0000
+   History:
+`,
+			want: []Commit{
+				{ID: "9a8b7c6d5e4f3a2b1c0d9e8f7a6b5c4d3e2f1a0b9c8d7e6f5a4b3c2d1e0f9a8b", Subject: "Export org.example.App", Date: "2026-01-02 03:04:05 +0000"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseCommitLog(tt.output); !slices.Equal(got, tt.want) {
+				t.Errorf("parseCommitLog() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package flatseek
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Installation is a location flatpak installs packages to
+type Installation struct {
+	ID          string
+	DisplayName string
+	Path        string
+}
+
+// returns the directory containing flatpak's system-wide configuration
+func flatpakConfigDir() string {
+	if dir := os.Getenv("FLATPAK_CONFIG_DIR"); dir != "" {
+		return dir
+	}
+	return "/etc/flatpak"
+}
+
+// returns the user and system installation along with custom installations
+// declared in /etc/flatpak/installations.d
+func listInstallations() []Installation {
+	system := os.Getenv("FLATPAK_SYSTEM_DIR")
+	if system == "" {
+		system = "/var/lib/flatpak"
+	}
+	user := os.Getenv("FLATPAK_USER_DIR")
+	if user == "" {
+		dataHome := os.Getenv("XDG_DATA_HOME")
+		if dataHome == "" {
+			home, _ := os.UserHomeDir()
+			dataHome = path.Join(home, ".local/share")
+		}
+		user = path.Join(dataHome, "flatpak")
+	}
+
+	installations := []Installation{
+		{ID: "user", DisplayName: "User installation", Path: user},
+		{ID: "system", DisplayName: "System installation", Path: system},
+	}
+	return append(installations, customInstallations()...)
+}
+
+// parses the installation keyfiles in /etc/flatpak/installations.d
+func customInstallations() []Installation {
+	files, _ := filepath.Glob(path.Join(flatpakConfigDir(), "installations.d", "*.conf"))
+	sort.Strings(files)
+
+	installations := []Installation{}
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			continue
+		}
+
+		var current *Installation
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if strings.HasPrefix(line, "[Installation \"") && strings.HasSuffix(line, "\"]") {
+				if current != nil && current.Path != "" {
+					installations = append(installations, *current)
+				}
+				current = &Installation{
+					ID: strings.TrimSuffix(strings.TrimPrefix(line, "[Installation \""), "\"]"),
+				}
+				continue
+			}
+			if strings.HasPrefix(line, "[") {
+				if current != nil && current.Path != "" {
+					installations = append(installations, *current)
+				}
+				current = nil
+				continue
+			}
+			k, v, found := strings.Cut(line, "=")
+			if current == nil || !found {
+				continue
+			}
+			switch strings.TrimSpace(k) {
+			case "Path":
+				current.Path = strings.TrimSpace(v)
+			case "DisplayName":
+				current.DisplayName = strings.TrimSpace(v)
+			}
+		}
+		if current != nil && current.Path != "" {
+			installations = append(installations, *current)
+		}
+		f.Close()
+	}
+	return installations
+}
+
+// returns the ids of all installations
+func installationIDs() []string {
+	ids := []string{}
+	for _, inst := range listInstallations() {
+		ids = append(ids, inst.ID)
+	}
+	return ids
+}
+
+// returns the flatpak command line option selecting an installation, none for all installations
+func installationFlag(installation string) []string {
+	switch installation {
+	case "":
+		return []string{}
+	case "user", "system":
+		return []string{"--" + installation}
+	}
+	return []string{"--installation=" + installation}
+}
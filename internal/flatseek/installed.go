@@ -0,0 +1,139 @@
+package flatseek
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// installedRefs is an index of all installed packages, keyed by their ref.
+// a ref may be installed in several installations
+type installedRefs struct {
+	locker *sync.RWMutex
+	refs   map[string][]Package
+	loaded bool
+}
+
+// newInstalledRefs creates an empty index
+func newInstalledRefs() *installedRefs {
+	return &installedRefs{
+		locker: &sync.RWMutex{},
+		refs:   map[string][]Package{},
+	}
+}
+
+// rebuilds our index of installed refs from the backend
+func (ps *UI) refreshInstalledRefs() error {
+	installed, err := ps.backend.ListInstalled()
+	if err != nil {
+		return err
+	}
+	ps.setInstalledRefs(installed)
+	return nil
+}
+
+// replaces our index of installed refs
+func (ps *UI) setInstalledRefs(installed []Package) {
+	refs := map[string][]Package{}
+	for _, pkg := range installed {
+		refs[pkg.Ref.String()] = append(refs[pkg.Ref.String()], pkg)
+	}
+
+	ps.installed.locker.Lock()
+	defer ps.installed.locker.Unlock()
+	ps.installed.refs = refs
+	ps.installed.loaded = true
+}
+
+// builds our index of installed refs unless that has been done already
+func (ps *UI) loadInstalledRefs() error {
+	ps.installed.locker.RLock()
+	loaded := ps.installed.loaded
+	ps.installed.locker.RUnlock()
+
+	if loaded {
+		return nil
+	}
+	return ps.refreshInstalledRefs()
+}
+
+// returns the installed package with the same ref in the same installation.
+// any installation matches if the package's installation is unknown
+func (ps *UI) getInstalledRef(pkg Package) (Package, bool) {
+	ps.installed.locker.RLock()
+	defer ps.installed.locker.RUnlock()
+
+	// the kind of search results is unknown
+	kinds := []string{pkg.Kind}
+	if pkg.Kind == "" {
+		kinds = []string{"app", "runtime"}
+	}
+	for _, kind := range kinds {
+		for _, ipkg := range ps.installed.refs[pkg.withKind(kind).String()] {
+			if pkg.Installation == "" || pkg.Installation == ipkg.Installation {
+				return ipkg, true
+			}
+		}
+	}
+	return Package{}, false
+}
+
+// checks our index if a package is installed
+func (ps *UI) pkgCheckInstalled(pkg Package) bool {
+	_, found := ps.getInstalledRef(pkg)
+	return found
+}
+
+// returns all installed packages with the search-term in their name or id
+func (ps *UI) searchInstalledRefs(term string) []Package {
+	ps.installed.locker.RLock()
+	defer ps.installed.locker.RUnlock()
+
+	term = strings.ToLower(term)
+	packages := []Package{}
+	for _, refs := range ps.installed.refs {
+		for _, pkg := range refs {
+			if strings.Contains(strings.ToLower(pkg.Name), term) ||
+				strings.Contains(strings.ToLower(pkg.ID), term) {
+				packages = append(packages, pkg)
+			}
+		}
+	}
+	return packages
+}
+
+// returns all installed packages originating from a remote
+func (ps *UI) installedFromRemote(remote Remote) []Package {
+	ps.installed.locker.RLock()
+	defer ps.installed.locker.RUnlock()
+
+	packages := []Package{}
+	for _, refs := range ps.installed.refs {
+		for _, pkg := range refs {
+			if pkg.Remote == remote.Name && pkg.Installation == remote.Installation {
+				packages = append(packages, pkg)
+			}
+		}
+	}
+	sort.Slice(packages, func(i, j int) bool {
+		return packages[i].Ref.String() < packages[j].Ref.String()
+	})
+	return packages
+}
+
+// refreshes the installed refs after a transaction and updates the package list.
+// cached search results, details and updates show the state before it, so they are dropped
+func (ps *UI) refreshInstalledState() {
+	ps.cacheSearch.Flush()
+	ps.cacheInfo.Flush()
+	go func() {
+		err := ps.refreshInstalledRefs()
+		ps.app.QueueUpdateDraw(func() {
+			if err != nil {
+				ps.displayMessage(err.Error(), true)
+				return
+			}
+			ps.updateInstalledState()
+		})
+	}()
+}
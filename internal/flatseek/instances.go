@@ -0,0 +1,229 @@
+package flatseek
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const (
+	instancesTitle   = "Running instances "
+	instancesRefresh = 2 * time.Second
+	clockTicks       = 100 // USER_HZ, the unit of the cpu times in /proc/<pid>/stat
+)
+
+// procSample is the cpu time of a process at a point in time
+type procSample struct {
+	ticks uint64
+	time  time.Time
+}
+
+// reads the cpu time (in clock ticks) and resident memory (in kB) of a process from /proc
+func readProcStats(pid int) (uint64, uint64, error) {
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return 0, 0, err
+	}
+	// the command name may contain spaces, so we split after its closing parenthesis
+	i := strings.LastIndex(string(stat), ")")
+	fields := strings.Fields(string(stat)[i+1:])
+	if i < 0 || len(fields) < 13 {
+		return 0, 0, fmt.Errorf("unexpected format of /proc/%d/stat", pid)
+	}
+	utime, _ := strconv.ParseUint(fields[11], 10, 64)
+	stime, _ := strconv.ParseUint(fields[12], 10, 64)
+
+	status, err := os.ReadFile(fmt.Sprintf("/proc/%d/status", pid))
+	if err != nil {
+		return 0, 0, err
+	}
+	var rss uint64
+	for _, line := range strings.Split(string(status), "\n") {
+		if v, found := strings.CutPrefix(line, "VmRSS:"); found {
+			rss, _ = strconv.ParseUint(strings.TrimSuffix(strings.TrimSpace(v), " kB"), 10, 64)
+			break
+		}
+	}
+	return utime + stime, rss, nil
+}
+
+// fills in cpu usage since the previous sample and memory of the instances' app processes
+func sampleInstances(instances []Instance, samples map[int]procSample) {
+	now := time.Now()
+	for i := range instances {
+		pid := instances[i].ChildPID
+		if pid == 0 {
+			pid = instances[i].PID
+		}
+		ticks, rss, err := readProcStats(pid)
+		if err != nil {
+			continue
+		}
+		instances[i].RSS = rss
+		if prev, ok := samples[pid]; ok && ticks >= prev.ticks {
+			elapsed := now.Sub(prev.time).Seconds()
+			if elapsed > 0 {
+				instances[i].CPU = float64(ticks-prev.ticks) / clockTicks / elapsed * 100
+			}
+		}
+		samples[pid] = procSample{ticks: ticks, time: now}
+	}
+}
+
+// formats a memory size given in kB
+func formatKB(kb uint64) string {
+	switch {
+	case kb >= 1024*1024:
+		return fmt.Sprintf("%.1f GB", float64(kb)/1024/1024)
+	case kb >= 1024:
+		return fmt.Sprintf("%.1f MB", float64(kb)/1024)
+	}
+	return fmt.Sprintf("%d kB", kb)
+}
+
+// checks if our list of instances is still shown
+func (ps *UI) instancesVisible(generation int) bool {
+	return generation == ps.instancesGeneration &&
+		ps.flexRight.GetItem(0) == ps.tableDetails &&
+		strings.HasSuffix(ps.tableDetails.GetTitle(), instancesTitle)
+}
+
+// displays the running flatpak instances and refreshes them periodically while they are shown
+func (ps *UI) displayInstances() {
+	ps.instancesGeneration++
+	generation := ps.instancesGeneration
+	ps.tableDetails.Clear().
+		SetTitle(" [::b]Retrieving running instances... ")
+
+	go func() {
+		samples := map[int]procSample{}
+		for first := true; ; first = false {
+			instances, err := ps.backend.Instances()
+			if err == nil {
+				sampleInstances(instances, samples)
+			}
+
+			// stop refreshing once another view replaced ours
+			shown := make(chan bool, 1)
+			ps.app.QueueUpdateDraw(func() {
+				if !first && !ps.instancesVisible(generation) {
+					shown <- false
+					return
+				}
+				if err != nil {
+					ps.tableDetails.SetTitle(" [::b]Error ")
+					ps.displayMessage(err.Error(), true)
+					shown <- false
+					return
+				}
+				ps.drawInstances(instances)
+				shown <- true
+			})
+			if !<-shown {
+				return
+			}
+			time.Sleep(instancesRefresh)
+		}
+	}()
+}
+
+// draws the list of running instances
+func (ps *UI) drawInstances(instances []Instance) {
+	row, _ := ps.tableDetails.GetOffset()
+	ps.tableDetails.Clear().
+		SetTitle(" [::b]" + ps.conf.Glyphs().Package + instancesTitle)
+
+	// remove "Latest news" if they were shown previously
+	if ps.flexRight.GetItemCount() == 2 {
+		ps.flexRight.RemoveItem(ps.flexRight.GetItem(1))
+	}
+
+	// header
+	columns := []string{"Instance  ", "App  ", "PID  ", "Branch  ", "Runtime  ", "CPU  ", "Memory  ", ""}
+	for i, col := range columns {
+		ps.tableDetails.SetCell(0, i, &tview.TableCell{
+			Text:            col,
+			Color:           ps.conf.Colors().PackagelistHeader,
+			BackgroundColor: ps.conf.Colors().DefaultBackground,
+		})
+	}
+
+	// lines
+	r := 2
+	for _, inst := range instances {
+		inst := inst
+		values := []string{
+			inst.ID,
+			"[::b]" + inst.App,
+			strconv.Itoa(inst.PID),
+			inst.Branch,
+			inst.Runtime,
+			fmt.Sprintf("%.1f%%", inst.CPU),
+			formatKB(inst.RSS),
+		}
+		for i, v := range values {
+			cell := &tview.TableCell{
+				Text:            v,
+				Color:           tcell.ColorWhite,
+				BackgroundColor: ps.conf.Colors().DefaultBackground,
+			}
+			if i == 1 {
+				cell.SetTextColor(ps.conf.Colors().Accent).
+					SetClickedFunc(func() bool {
+						ps.showInstanceApp(inst)
+						return true
+					})
+			}
+			ps.tableDetails.SetCell(r, i, cell)
+		}
+		ps.tableDetails.SetCell(r, len(values), &tview.TableCell{
+			Text:            " [::b]Kill",
+			Color:           ps.conf.Colors().SettingsFieldText,
+			BackgroundColor: ps.conf.Colors().SearchBar,
+			Clicked: func() bool {
+				if err := ps.backend.Kill(inst); err != nil {
+					ps.displayMessage(err.Error(), true)
+					return true
+				}
+				ps.displayMessage("Instance "+inst.ID+" of "+inst.App+" has been killed", false)
+				ps.displayInstances()
+				return true
+			},
+		})
+		r++
+	}
+
+	// no instances message
+	if len(instances) == 0 {
+		ps.tableDetails.SetCell(r+1, 0, &tview.TableCell{
+			Text:            "No running instances",
+			Color:           ps.conf.Colors().PackagelistHeader,
+			BackgroundColor: ps.conf.Colors().DefaultBackground,
+		})
+	}
+
+	// set nil to avoid printing package details when resizing
+	ps.selectedPackage = nil
+	ps.tableDetails.SetOffset(row, 0)
+}
+
+// shows the details of an instance's app
+func (ps *UI) showInstanceApp(inst Instance) {
+	if err := ps.loadInstalledRefs(); err != nil {
+		ps.displayMessage(err.Error(), true)
+		return
+	}
+	pkg := Package{Ref: Ref{Kind: "app", ID: inst.App, Arch: inst.Arch, Branch: inst.Branch}}
+	ipkg, found := ps.getInstalledRef(pkg)
+	if !found {
+		ps.displayMessage(inst.App+" is not installed in the selected installation", true)
+		return
+	}
+	ps.selectedPackage = &ipkg
+	ps.drawPackageInfo(ipkg, ps.width)
+}
@@ -0,0 +1,146 @@
+package flatseek
+
+import (
+	"strings"
+
+	"github.com/XnLogicaL/flatseek/internal/config"
+	"github.com/rivo/tview"
+)
+
+// returns the arguments for "flatpak run" to launch an app with the options of a profile
+func launchArgs(pkg Package, profile config.LaunchProfile) []string {
+	args := []string{"run"}
+	args = append(args, installationFlag(pkg.Installation)...)
+	if profile.Branch != "" {
+		args = append(args, "--branch="+profile.Branch)
+	}
+	if profile.Shell {
+		args = append(args, "--command=sh")
+	} else if profile.Command != "" {
+		args = append(args, "--command="+profile.Command)
+	}
+	if profile.Devel {
+		args = append(args, "--devel")
+	}
+	for _, env := range splitList(profile.Environment) {
+		args = append(args, "--env="+env)
+	}
+	args = append(args, pkg.ID)
+	if !profile.Shell {
+		args = append(args, strings.Fields(profile.Args)...)
+	}
+	return args
+}
+
+// runs an app; a shell into its sandbox needs our terminal, apps are started detached
+func (ps *UI) runApp(pkg Package, profile config.LaunchProfile) {
+	if profile.Shell {
+		ps.runTransaction(func() error {
+			return ps.backend.Run(pkg, profile)
+		})
+		return
+	}
+	if err := ps.backend.Run(pkg, profile); err != nil {
+		ps.displayMessage(err.Error(), true)
+		return
+	}
+	ps.displayMessage(pkg.ID+" has been started", false)
+}
+
+// displays a form to run the selected app with options which can be saved as launch profiles
+func (ps *UI) displayRunDialog(pkg Package) {
+	if ipkg, found := ps.getInstalledRef(pkg); found {
+		pkg = ipkg
+	}
+	if pkg.Kind == "runtime" || !pkg.IsInstalled {
+		ps.displayMessage("Only installed apps can be run", true)
+		return
+	}
+
+	profiles, err := config.LoadLaunchProfiles()
+	if err != nil {
+		ps.displayMessage(err.Error(), true)
+	}
+	names := []string{"New profile"}
+	for _, p := range profiles[pkg.ID] {
+		names = append(names, p.Name)
+	}
+
+	form := tview.NewForm().
+		AddDropDown("Profile: ", names, 0, nil).
+		AddInputField("Name: ", "", 30, nil, nil).
+		AddInputField("Branch: ", pkg.Branch, 30, nil, nil).
+		AddInputField("Command: ", "", 30, nil, nil).
+		AddInputField("Arguments: ", "", 50, nil, nil).
+		AddInputField("Environment: ", "", 50, nil, nil).
+		AddCheckbox("Devel: ", false, nil).
+		AddCheckbox("Shell into sandbox: ", false, nil)
+
+	input := func(label string) *tview.InputField {
+		return form.GetFormItemByLabel(label).(*tview.InputField)
+	}
+	checkbox := func(label string) *tview.Checkbox {
+		return form.GetFormItemByLabel(label).(*tview.Checkbox)
+	}
+
+	// fill in the fields when a saved profile is chosen
+	form.GetFormItemByLabel("Profile: ").(*tview.DropDown).SetSelectedFunc(func(text string, index int) {
+		if index == 0 {
+			return
+		}
+		p := profiles[pkg.ID][index-1]
+		input("Name: ").SetText(p.Name)
+		input("Branch: ").SetText(p.Branch)
+		input("Command: ").SetText(p.Command)
+		input("Arguments: ").SetText(p.Args)
+		input("Environment: ").SetText(p.Environment)
+		checkbox("Devel: ").SetChecked(p.Devel)
+		checkbox("Shell into sandbox: ").SetChecked(p.Shell)
+	})
+
+	// reads the profile from our form
+	profile := func() config.LaunchProfile {
+		return config.LaunchProfile{
+			Name:        strings.TrimSpace(input("Name: ").GetText()),
+			Branch:      strings.TrimSpace(input("Branch: ").GetText()),
+			Command:     strings.TrimSpace(input("Command: ").GetText()),
+			Args:        input("Arguments: ").GetText(),
+			Environment: input("Environment: ").GetText(),
+			Devel:       checkbox("Devel: ").IsChecked(),
+			Shell:       checkbox("Shell into sandbox: ").IsChecked(),
+		}
+	}
+
+	form.AddButton("Run", func() {
+		ps.closeDialogForm()
+		ps.runApp(pkg, profile())
+	}).
+		AddButton("Save profile", func() {
+			p := profile()
+			if p.Name == "" {
+				ps.displayMessage("A name is required to save a profile", true)
+				return
+			}
+			profiles.Set(pkg.ID, p)
+			if err := profiles.Save(); err != nil {
+				ps.displayMessage(err.Error(), true)
+				return
+			}
+			ps.displayMessage("Profile "+p.Name+" has been saved", false)
+			ps.displayRunDialog(pkg)
+		}).
+		AddButton("Delete profile", func() {
+			profiles.Remove(pkg.ID, profile().Name)
+			if err := profiles.Save(); err != nil {
+				ps.displayMessage(err.Error(), true)
+				return
+			}
+			ps.displayRunDialog(pkg)
+		}).
+		AddButton("Cancel", func() {
+			ps.closeDialogForm()
+		})
+	form.SetTitle(" [::b]" + ps.conf.Glyphs().Package + "Run " + pkg.ID + " ")
+
+	ps.showDialogForm(form)
+}
@@ -0,0 +1,207 @@
+package flatseek
+
+import (
+	"path"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// checks if a mask / pin pattern like "org.x.*" or "runtime/org.x.Y//stable" matches a ref
+func (m Mask) matches(ref Ref) bool {
+	pattern, err := ParseRef(m.Pattern)
+	if err != nil {
+		return false
+	}
+	if pattern.Kind != "" && ref.Kind != "" && pattern.Kind != ref.Kind {
+		return false
+	}
+	for _, p := range [][2]string{
+		{pattern.ID, ref.ID},
+		{pattern.Arch, ref.Arch},
+		{pattern.Branch, ref.Branch},
+	} {
+		if p[0] == "" {
+			continue
+		}
+		if ok, _ := path.Match(p[0], p[1]); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// returns a short name for the type of a mask
+func (m Mask) kind() string {
+	if m.Pin {
+		return "pin"
+	}
+	return "mask"
+}
+
+// checks if a package is masked in its installation
+func isMasked(pkg Package, masks []Mask) bool {
+	for _, m := range masks {
+		if !m.Pin && (pkg.Installation == "" || m.Installation == pkg.Installation) && m.matches(pkg.Ref) {
+			return true
+		}
+	}
+	return false
+}
+
+// checks if a package is pinned in its installation
+func isPinned(pkg Package, masks []Mask) bool {
+	for _, m := range masks {
+		if m.Pin && (pkg.Installation == "" || m.Installation == pkg.Installation) && m.matches(pkg.Ref) {
+			return true
+		}
+	}
+	return false
+}
+
+// returns the pattern we use to mask / pin a package from the list of updates:
+// apps are masked by id, runtimes pinned with their full ref
+func maskForPackage(pkg Package) Mask {
+	if pkg.Kind == "runtime" {
+		return Mask{Pattern: pkg.Ref.String(), Installation: pkg.Installation, Pin: true}
+	}
+	return Mask{Pattern: pkg.ID, Installation: pkg.Installation}
+}
+
+// adds or removes a mask / pin and refreshes the list of updates
+func (ps *UI) changeMask(mask Mask, remove bool) {
+	ps.runTransaction(func() error {
+		if remove {
+			return ps.backend.RemoveMask(mask)
+		}
+		return ps.backend.AddMask(mask)
+	})
+	ps.cacheInfo.Delete("#upgrades#")
+}
+
+// removes all masks matching a package and refreshes the list of updates
+func (ps *UI) unmaskPackage(pkg Package) {
+	ps.removeMasks(pkg, isMasked)
+}
+
+// removes all pins matching a package and refreshes the list of updates
+func (ps *UI) unpinPackage(pkg Package) {
+	ps.removeMasks(pkg, isPinned)
+}
+
+// removes the masks / pins of a package which the match function applies to
+func (ps *UI) removeMasks(pkg Package, match func(Package, []Mask) bool) {
+	ps.runTransaction(func() error {
+		masks, err := ps.backend.Masks()
+		if err != nil {
+			return err
+		}
+		for _, m := range masks {
+			if match(pkg, []Mask{m}) {
+				if err = ps.backend.RemoveMask(m); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	ps.cacheInfo.Delete("#upgrades#")
+}
+
+// displays the list of masks and pins of our installations
+func (ps *UI) displayMasks() {
+	ps.tableDetails.Clear().
+		SetTitle(" [::b]Retrieving masks and pins... ")
+
+	go func() {
+		ps.locker.Lock()
+		ps.startSpinner()
+		defer func() {
+			ps.locker.Unlock()
+			ps.stopSpinner()
+		}()
+
+		masks, err := ps.backend.Masks()
+		ps.app.QueueUpdateDraw(func() {
+			if err != nil {
+				ps.tableDetails.SetTitle(" [::b]Error ")
+				ps.displayMessage(err.Error(), true)
+				return
+			}
+			ps.drawMasks(masks)
+		})
+	}()
+}
+
+// draws the list of masks and pins with a button to remove each of them
+func (ps *UI) drawMasks(masks []Mask) {
+	ps.tableDetails.Clear().
+		SetTitle(" [::b]" + ps.conf.Glyphs().Upgrades + "Masks and pins ")
+
+	// header
+	columns := []string{"Pattern  ", "Installation  ", "Type  ", ""}
+	for i, col := range columns {
+		ps.tableDetails.SetCell(0, i, &tview.TableCell{
+			Text:            col,
+			Color:           ps.conf.Colors().PackagelistHeader,
+			BackgroundColor: ps.conf.Colors().DefaultBackground,
+		})
+	}
+
+	// lines
+	r := 2
+	for _, mask := range masks {
+		mask := mask
+		ps.tableDetails.SetCell(r, 0, &tview.TableCell{
+			Text:            "[::b]" + mask.Pattern,
+			Color:           ps.conf.Colors().Accent,
+			BackgroundColor: ps.conf.Colors().DefaultBackground,
+		}).
+			SetCell(r, 1, &tview.TableCell{
+				Text:            mask.Installation,
+				Color:           tcell.ColorWhite,
+				BackgroundColor: ps.conf.Colors().DefaultBackground,
+			}).
+			SetCell(r, 2, &tview.TableCell{
+				Text:            mask.kind(),
+				Color:           ps.conf.Colors().PackagelistHeader,
+				BackgroundColor: ps.conf.Colors().DefaultBackground,
+			}).
+			SetCell(r, 3, &tview.TableCell{
+				Text:            " [::b]Remove",
+				Color:           ps.conf.Colors().SettingsFieldText,
+				BackgroundColor: ps.conf.Colors().SearchBar,
+				Clicked: func() bool {
+					ps.changeMask(mask, true)
+					ps.displayMasks()
+					return true
+				},
+			})
+		r++
+	}
+
+	// no masks message and back button
+	r++
+	if len(masks) == 0 {
+		ps.tableDetails.SetCell(r, 0, &tview.TableCell{
+			Text:            "No masks or pins configured",
+			Color:           ps.conf.Colors().PackagelistHeader,
+			BackgroundColor: ps.conf.Colors().DefaultBackground,
+		})
+		r += 2
+	}
+	ps.tableDetails.SetCell(r, 0, &tview.TableCell{
+		Text:            " [::b]Back",
+		Align:           tview.AlignCenter,
+		Color:           ps.conf.Colors().SettingsFieldText,
+		BackgroundColor: ps.conf.Colors().SearchBar,
+		Clicked: func() bool {
+			ps.displayUpgradable()
+			return true
+		},
+	})
+
+	// set nil to avoid printing package details when resizing
+	ps.selectedPackage = nil
+	ps.tableDetails.ScrollToBeginning()
+}
@@ -0,0 +1,28 @@
+package flatseek
+
+import "testing"
+
+func TestMaskMatches(t *testing.T) {
+	tests := []struct {
+		pattern string
+		ref     string
+		want    bool
+	}{
+		{"org.gimp.*", "app/org.gimp.GIMP/x86_64/stable", true},
+		{"org.gimp.*", "app/org.gnome.Calculator/x86_64/stable", false},
+		{"org.gimp.GIMP", "app/org.gimp.GIMP/x86_64/beta", true},
+		{"runtime/org.gnome.Platform/x86_64/45", "runtime/org.gnome.Platform/x86_64/45", true},
+		{"runtime/org.gnome.Platform/x86_64/45", "runtime/org.gnome.Platform/x86_64/46", false},
+		{"runtime/org.gnome.Platform/x86_64/45", "runtime/org.gnome.Platform/aarch64/45", false},
+		{"org.gnome.Platform//4*", "runtime/org.gnome.Platform/aarch64/46", true},
+		{"app/org.gnome.*", "runtime/org.gnome.Platform/x86_64/46", false},
+		{"app/org.gnome.*", "org.gnome.Platform/x86_64/46", true},
+		{"", "app/org.gimp.GIMP/x86_64/stable", false},
+	}
+	for _, tt := range tests {
+		ref, _ := ParseRef(tt.ref)
+		if got := (Mask{Pattern: tt.pattern}).matches(ref); got != tt.want {
+			t.Errorf("%q matches %q = %v, want %v", tt.pattern, tt.ref, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package flatseek
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/XnLogicaL/flatseek/internal/util"
+)
+
+// returns a unified diff of two texts with all lines as context
+func diffLines(a, b string) []string {
+	x := strings.Split(strings.TrimRight(a, "\n"), "\n")
+	y := strings.Split(strings.TrimRight(b, "\n"), "\n")
+
+	// lengths of the longest common subsequences of the remaining lines
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	lines := []string{}
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			lines = append(lines, " "+x[i])
+			i++
+			j++
+		case i < len(x) && (j == len(y) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, "-"+x[i])
+			i++
+		default:
+			lines = append(lines, "+"+y[j])
+			j++
+		}
+	}
+	return lines
+}
+
+// returns the values of a permission field which are granted by b but not by a
+func addedPermissions(a, b Permissions) []permissionField {
+	added := []permissionField{}
+	old := a.fields()
+	for i, f := range b.fields() {
+		values := []string{}
+		for _, v := range f.values {
+			if util.IndexOf(old[i].values, v) < 0 {
+				values = append(values, v)
+			}
+		}
+		if len(values) > 0 {
+			added = append(added, permissionField{f.name, values})
+		}
+	}
+	return added
+}
+
+// returns the extension points of a metadata file
+func extensionPoints(kf keyFile) []string {
+	points := []string{}
+	for group := range kf {
+		if name, found := strings.CutPrefix(group, "Extension "); found {
+			points = append(points, name)
+		}
+	}
+	sort.Strings(points)
+	return points
+}
+
+// summarizes what changes between two metadata files:
+// runtime and sdk bumps, new and dropped permissions and extension points
+func metadataChanges(installed, available string) []string {
+	a, b := parseKeyFile(installed), parseKeyFile(available)
+	changes := []string{}
+
+	for _, key := range []string{"runtime", "sdk"} {
+		from, to := a["Application"][key], b["Application"][key]
+		if from == to {
+			continue
+		}
+		changes = append(changes, fmt.Sprintf("! %s: %s -> %s", key, from, to))
+	}
+
+	for _, f := range addedPermissions(parsePermissions(installed), parsePermissions(available)) {
+		for _, v := range f.values {
+			line := "+ " + f.name + ": " + v
+			if isDangerousPermission(f.name, v) {
+				line += " (!)"
+			}
+			changes = append(changes, line)
+		}
+	}
+	for _, f := range addedPermissions(parsePermissions(available), parsePermissions(installed)) {
+		for _, v := range f.values {
+			changes = append(changes, "- "+f.name+": "+v)
+		}
+	}
+
+	oldPoints, newPoints := extensionPoints(a), extensionPoints(b)
+	for _, p := range newPoints {
+		if util.IndexOf(oldPoints, p) < 0 {
+			changes = append(changes, "+ extension point: "+p)
+		}
+	}
+	for _, p := range oldPoints {
+		if util.IndexOf(newPoints, p) < 0 {
+			changes = append(changes, "- extension point: "+p)
+		}
+	}
+	return changes
+}
+
+// returns the sandbox grants an update adds: sockets, filesystems, devices and D-Bus names,
+// formatted like "sockets=x11" and marked with "(!)" if they are dangerous
+func sandboxGrants(installed, available string) []string {
+	keys := map[string]string{
+		"Sockets":          "sockets",
+		"Devices":          "devices",
+		"Filesystems":      "filesystems",
+		"Session bus talk": "talk-name",
+		"Session bus own":  "own-name",
+		"System bus talk":  "system-talk-name",
+		"System bus own":   "system-own-name",
+	}
+	grants := []string{}
+	for _, f := range addedPermissions(parsePermissions(installed), parsePermissions(available)) {
+		key, ok := keys[f.name]
+		if !ok {
+			continue
+		}
+		for _, v := range f.values {
+			grant := key + "=" + v
+			if isDangerousPermission(f.name, v) {
+				grant += " (!)"
+			}
+			grants = append(grants, grant)
+		}
+	}
+	return grants
+}
+
+// returns the metadata of the installed commit and of the remote's latest commit of a package
+func metadataPair(b Backend, pkg Package) (string, string, error) {
+	installed := pkg
+	installed.IsInstalled = true
+	old, err := b.Metadata(installed)
+	if err != nil {
+		return "", "", err
+	}
+	remote := pkg
+	remote.IsInstalled = false
+	latest, err := b.Metadata(remote)
+	if err != nil {
+		return "", "", err
+	}
+	return old, latest, nil
+}
+
+// shows the differences between the metadata of the installed and the available commit of a package
+func (ps *UI) displayMetadataDiff(pkg Package) {
+	ps.selectedPackage = &pkg
+	ps.sourceSearch = ""
+	ps.sourceMatch = 0
+	ps.sourceSearching = false
+	key := "#diff#" + pkg.key()
+
+	if files, found := ps.cachePkgbuild.Get(key); found {
+		ps.sourceFiles = files.([]sourceFile)
+		ps.drawSource(pkg.ID)
+		return
+	}
+
+	ps.tableDetails.SetTitle(" [::b]" + pkg.ID + " - Comparing metadata... ")
+	go func() {
+		ps.locker.Lock()
+		ps.startSpinner()
+		defer func() {
+			ps.locker.Unlock()
+			ps.stopSpinner()
+		}()
+
+		installed, available, err := metadataPair(ps.backend, pkg)
+
+		ps.app.QueueUpdateDraw(func() {
+			if err != nil {
+				ps.tableDetails.SetTitle(" [::b]Error ")
+				ps.displayMessage(err.Error(), true)
+				return
+			}
+			changes := metadataChanges(installed, available)
+			if len(changes) == 0 {
+				changes = []string{"  no changes of runtime, permissions or extension points"}
+			}
+			from := shortCommit(pkg.LocalCommit)
+			if from == "" {
+				from = "installed"
+			}
+			to := shortCommit(pkg.Commit)
+			if to == "" {
+				to = pkg.Remote
+			}
+			diff := append([]string{"--- " + from, "+++ " + to}, diffLines(installed, available)...)
+			files := []sourceFile{
+				{Title: "changes", Lexer: "diff", Content: strings.Join(changes, "\n") + "\n"},
+				{Title: "metadata " + from + " -> " + to, Lexer: "diff", Content: strings.Join(diff, "\n") + "\n"},
+			}
+			if !ps.conf.DisableCache {
+				ps.cachePkgbuild.Set(key, files, time.Duration(ps.conf.CacheExpiry)*time.Minute)
+			}
+			ps.sourceFiles = files
+			ps.drawSource(pkg.ID)
+		})
+	}()
+}
@@ -0,0 +1,97 @@
+package flatseek
+
+import (
+	"slices"
+	"testing"
+)
+
+const (
+	testInstalledMetadata = `[Application]
+name=org.x.Y
+runtime=org.gnome.Platform/x86_64/45
+sdk=org.gnome.Sdk/x86_64/45
+
+[Context]
+sockets=wayland;x11;
+filesystems=xdg-download;
+`
+	testAvailableMetadata = `[Application]
+name=org.x.Y
+runtime=org.gnome.Platform/x86_64/46
+sdk=org.gnome.Sdk/x86_64/45
+
+[Context]
+sockets=wayland;pulseaudio;
+devices=all;
+filesystems=xdg-download;home;
+
+[Session Bus Policy]
+org.freedesktop.Flatpak=talk
+
+[Extension org.x.Y.Plugin]
+directory=plugins
+`
+)
+
+func TestSandboxGrants(t *testing.T) {
+	tests := []struct {
+		name                 string
+		installed, available string
+		want                 []string
+	}{
+		{"unchanged", testInstalledMetadata, testInstalledMetadata, []string{}},
+		{
+			"added", testInstalledMetadata, testAvailableMetadata,
+			[]string{"sockets=pulseaudio", "devices=all (!)", "filesystems=home (!)", "talk-name=org.freedesktop.Flatpak (!)"},
+		},
+		{"removed", testAvailableMetadata, testInstalledMetadata, []string{"sockets=x11 (!)"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sandboxGrants(tt.installed, tt.available); !slices.Equal(got, tt.want) {
+				t.Errorf("sandboxGrants() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMetadataChanges(t *testing.T) {
+	tests := []struct {
+		name                 string
+		installed, available string
+		want                 []string
+	}{
+		{"unchanged", testInstalledMetadata, testInstalledMetadata, []string{}},
+		{
+			"update", testInstalledMetadata, testAvailableMetadata,
+			[]string{
+				"! runtime: org.gnome.Platform/x86_64/45 -> org.gnome.Platform/x86_64/46",
+				"+ Sockets: pulseaudio",
+				"+ Devices: all (!)",
+				"+ Filesystems: home (!)",
+				"+ Session bus talk: org.freedesktop.Flatpak (!)",
+				"- Sockets: x11",
+				"+ extension point: org.x.Y.Plugin",
+			},
+		},
+		{
+			"downgrade", testAvailableMetadata, testInstalledMetadata,
+			[]string{
+				"! runtime: org.gnome.Platform/x86_64/46 -> org.gnome.Platform/x86_64/45",
+				"+ Sockets: x11 (!)",
+				"- Sockets: pulseaudio",
+				"- Devices: all",
+				"- Filesystems: home",
+				"- Session bus talk: org.freedesktop.Flatpak",
+				"- extension point: org.x.Y.Plugin",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := metadataChanges(tt.installed, tt.available); !slices.Equal(got, tt.want) {
+				t.Errorf("metadataChanges() =\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,338 @@
+package flatseek
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/XnLogicaL/flatseek/internal/util"
+	"github.com/rivo/tview"
+)
+
+// options offered in the overrides editor
+var (
+	overrideShares  = []string{"network", "ipc"}
+	overrideSockets = []string{"x11", "wayland", "fallback-x11", "pulseaudio", "session-bus", "system-bus", "ssh-auth", "pcsc", "cups", "gpg-agent"}
+	overrideDevices = []string{"dri", "input", "usb", "kvm", "shm", "all"}
+)
+
+// Overrides are the permission changes of an app made with "flatpak override".
+// Revoked permissions are prefixed with "!", environment variables are "NAME=value" or "!NAME" if unset.
+// Bus names are "name=policy" with the policies talk, own or none, policies "subsystem.key=value".
+type Overrides struct {
+	Shared      []string
+	Sockets     []string
+	Devices     []string
+	Features    []string
+	Filesystems []string
+	Persistent  []string
+	SessionBus  []string
+	SystemBus   []string
+	Policies    []string
+	Environment []string
+}
+
+// returns the entries of a bus policy group as "name=policy"
+func busPolicies(kf keyFile, group string) []string {
+	names := []string{}
+	for k, v := range kf[group] {
+		names = append(names, k+"="+v)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parses the output of "flatpak override --show"
+func parseOverrides(data string) Overrides {
+	kf := parseKeyFile(data)
+
+	env := []string{}
+	for k, v := range kf["Environment"] {
+		env = append(env, k+"="+v)
+	}
+	for _, k := range kf.list("Context", "unset-environment") {
+		env = append(env, "!"+k)
+	}
+	sort.Strings(env)
+
+	// [Policy subsystem] groups with lists of values
+	policies := []string{}
+	for group := range kf {
+		subsystem, found := strings.CutPrefix(group, "Policy ")
+		if !found {
+			continue
+		}
+		for key := range kf[group] {
+			for _, v := range kf.list(group, key) {
+				if strings.HasPrefix(v, "!") {
+					policies = append(policies, "!"+subsystem+"."+key+"="+v[1:])
+				} else {
+					policies = append(policies, subsystem+"."+key+"="+v)
+				}
+			}
+		}
+	}
+	sort.Strings(policies)
+
+	return Overrides{
+		Shared:      kf.list("Context", "shared"),
+		Sockets:     kf.list("Context", "sockets"),
+		Devices:     kf.list("Context", "devices"),
+		Features:    kf.list("Context", "features"),
+		Filesystems: kf.list("Context", "filesystems"),
+		Persistent:  kf.list("Context", "persistent"),
+		SessionBus:  busPolicies(kf, "Session Bus Policy"),
+		SystemBus:   busPolicies(kf, "System Bus Policy"),
+		Policies:    policies,
+		Environment: env,
+	}
+}
+
+// returns the flatpak override arguments which apply our overrides
+func (o Overrides) args() []string {
+	args := []string{}
+	add := func(values []string, grant, revoke string) {
+		for _, v := range values {
+			if strings.HasPrefix(v, "!") {
+				args = append(args, revoke+"="+v[1:])
+			} else {
+				args = append(args, grant+"="+v)
+			}
+		}
+	}
+	bus := func(names []string, prefix string) {
+		options := map[string]string{"talk": "talk-name", "own": "own-name", "none": "no-talk-name"}
+		for _, n := range names {
+			name, policy, _ := strings.Cut(n, "=")
+			// "see" can't be set with flatpak override
+			if option, ok := options[policy]; ok {
+				args = append(args, prefix+option+"="+name)
+			}
+		}
+	}
+	add(o.Shared, "--share", "--unshare")
+	add(o.Sockets, "--socket", "--nosocket")
+	add(o.Devices, "--device", "--nodevice")
+	add(o.Features, "--allow", "--disallow")
+	add(o.Filesystems, "--filesystem", "--nofilesystem")
+	add(o.Persistent, "--persist", "--persist")
+	bus(o.SessionBus, "--")
+	bus(o.SystemBus, "--system-")
+	add(o.Policies, "--add-policy", "--remove-policy")
+	add(o.Environment, "--env", "--unset-env")
+	return args
+}
+
+// returns the permissions resulting from applying the overrides
+func (p Permissions) withOverrides(o Overrides) Permissions {
+	p.Shared = mergeOverrides(p.Shared, o.Shared, func(v string) string { return v })
+	p.Sockets = mergeOverrides(p.Sockets, o.Sockets, func(v string) string { return v })
+	p.Devices = mergeOverrides(p.Devices, o.Devices, func(v string) string { return v })
+	p.Features = mergeOverrides(p.Features, o.Features, func(v string) string { return v })
+	p.Filesystems = mergeOverrides(p.Filesystems, o.Filesystems, func(v string) string {
+		return strings.TrimSuffix(strings.TrimSuffix(strings.TrimSuffix(v, ":ro"), ":rw"), ":create")
+	})
+	p.SessionTalk, p.SessionOwn = mergeBusOverrides(p.SessionTalk, p.SessionOwn, o.SessionBus)
+	p.SystemTalk, p.SystemOwn = mergeBusOverrides(p.SystemTalk, p.SystemOwn, o.SystemBus)
+	p.Environment = mergeOverrides(p.Environment, o.Environment, func(v string) string {
+		name, _, _ := strings.Cut(v, "=")
+		return name
+	})
+	return p
+}
+
+// applies bus name overrides to the names an app may talk to and own
+func mergeBusOverrides(talk, own, overrides []string) ([]string, []string) {
+	talk, own = append([]string{}, talk...), append([]string{}, own...)
+	for _, o := range overrides {
+		name, policy, _ := strings.Cut(o, "=")
+		if i := util.IndexOf(talk, name); i >= 0 {
+			talk = append(talk[:i], talk[i+1:]...)
+		}
+		if i := util.IndexOf(own, name); i >= 0 {
+			own = append(own[:i], own[i+1:]...)
+		}
+		switch policy {
+		case "talk":
+			talk = append(talk, name)
+		case "own":
+			own = append(own, name)
+		}
+	}
+	return talk, own
+}
+
+// applies overrides to a list of permissions; the key function tells which entries replace each other
+func mergeOverrides(base, overrides []string, key func(string) string) []string {
+	result := append([]string{}, base...)
+	for _, o := range overrides {
+		revoke := strings.HasPrefix(o, "!")
+		k := key(strings.TrimPrefix(o, "!"))
+
+		merged := []string{}
+		for _, v := range result {
+			if key(v) != k {
+				merged = append(merged, v)
+			}
+		}
+		if !revoke {
+			merged = append(merged, o)
+		}
+		result = merged
+	}
+	return result
+}
+
+// returns the overrides of the given options which differ from the metadata
+func toggledOverrides(options, base []string, checked func(string) bool) []string {
+	overrides := []string{}
+	for _, opt := range options {
+		granted := checked(opt)
+		if granted == (util.IndexOf(base, opt) >= 0) {
+			continue
+		}
+		if granted {
+			overrides = append(overrides, opt)
+		} else {
+			overrides = append(overrides, "!"+opt)
+		}
+	}
+	return overrides
+}
+
+// returns the overrides of values our editor has no option for, so they are kept
+func otherOverrides(options, overrides []string) []string {
+	others := []string{}
+	for _, o := range overrides {
+		if util.IndexOf(options, strings.TrimPrefix(o, "!")) < 0 {
+			others = append(others, o)
+		}
+	}
+	return others
+}
+
+// splits a semicolon separated list, as entered in our input fields
+func splitList(text string) []string {
+	values := []string{}
+	for _, v := range strings.Split(text, ";") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// displays the overrides editor for the selected app along with its effective permissions
+func (ps *UI) displayOverrides() {
+	if ps.selectedPackage == nil {
+		return
+	}
+	pkg := *ps.selectedPackage
+	if pkg.Kind == "runtime" || !ps.pkgCheckInstalled(pkg) {
+		ps.displayMessage("Overrides can only be edited for installed apps", true)
+		return
+	}
+	if ipkg, found := ps.getInstalledRef(pkg); found {
+		pkg.Installation = ipkg.Installation
+		pkg.IsInstalled = true
+	}
+
+	metadata := pkg.Metadata
+	var err error
+	if metadata == "" {
+		if metadata, err = ps.backend.Metadata(pkg); err != nil {
+			ps.displayMessage(err.Error(), true)
+			return
+		}
+	}
+	current, err := ps.backend.Overrides(pkg)
+	if err != nil {
+		ps.displayMessage(err.Error(), true)
+		return
+	}
+	perms := parsePermissions(metadata)
+	effective := perms.withOverrides(current)
+
+	form := tview.NewForm()
+	checkboxes := func(kind string, options, granted []string) {
+		for _, opt := range options {
+			form.AddCheckbox(kind+" "+opt+": ", util.IndexOf(granted, opt) >= 0, nil)
+		}
+	}
+	checkboxes("Share", overrideShares, effective.Shared)
+	checkboxes("Socket", overrideSockets, effective.Sockets)
+	checkboxes("Device", overrideDevices, effective.Devices)
+	form.AddInputField("Features: ", strings.Join(current.Features, ";"), 50, nil, nil).
+		AddInputField("Filesystems: ", strings.Join(current.Filesystems, ";"), 50, nil, nil).
+		AddInputField("Persistent: ", strings.Join(current.Persistent, ";"), 50, nil, nil).
+		AddInputField("Session bus: ", strings.Join(current.SessionBus, ";"), 50, nil, nil).
+		AddInputField("System bus: ", strings.Join(current.SystemBus, ";"), 50, nil, nil).
+		AddInputField("Policies: ", strings.Join(current.Policies, ";"), 50, nil, nil).
+		AddInputField("Environment: ", strings.Join(current.Environment, ";"), 50, nil, nil)
+
+	// reads the overrides from our form
+	overrides := func() Overrides {
+		checked := func(kind string) func(string) bool {
+			return func(opt string) bool {
+				cb, ok := form.GetFormItemByLabel(kind + " " + opt + ": ").(*tview.Checkbox)
+				return ok && cb.IsChecked()
+			}
+		}
+		list := func(label string) []string {
+			return splitList(form.GetFormItemByLabel(label).(*tview.InputField).GetText())
+		}
+		return Overrides{
+			Shared:      append(toggledOverrides(overrideShares, perms.Shared, checked("Share")), otherOverrides(overrideShares, current.Shared)...),
+			Sockets:     append(toggledOverrides(overrideSockets, perms.Sockets, checked("Socket")), otherOverrides(overrideSockets, current.Sockets)...),
+			Devices:     append(toggledOverrides(overrideDevices, perms.Devices, checked("Device")), otherOverrides(overrideDevices, current.Devices)...),
+			Features:    list("Features: "),
+			Filesystems: list("Filesystems: "),
+			Persistent:  list("Persistent: "),
+			SessionBus:  list("Session bus: "),
+			SystemBus:   list("System bus: "),
+			Policies:    list("Policies: "),
+			Environment: list("Environment: "),
+		}
+	}
+
+	// show the effective permissions whenever something changes
+	drawEffective := func() {
+		ps.tableDetails.Clear().
+			SetTitle(" [::b]" + ps.conf.Glyphs().Package + "Effective permissions ")
+		ps.drawPermissions(perms.withOverrides(overrides()), 0)
+		ps.tableDetails.ScrollToBeginning()
+	}
+	for i := 0; i < form.GetFormItemCount(); i++ {
+		switch item := form.GetFormItem(i).(type) {
+		case *tview.Checkbox:
+			item.SetChangedFunc(func(checked bool) { drawEffective() })
+		case *tview.InputField:
+			item.SetChangedFunc(func(text string) { drawEffective() })
+		}
+	}
+
+	pkg.Metadata = metadata
+	back := func() {
+		ps.closeDialogForm()
+		ps.selectedPackage = &pkg
+		ps.drawPackageInfo(pkg, ps.width)
+	}
+	apply := func(o Overrides) {
+		ps.runTransaction(func() error {
+			return ps.backend.SetOverrides(pkg, o)
+		})
+		back()
+	}
+	form.AddButton("Apply", func() {
+		apply(overrides())
+	}).
+		AddButton("Reset", func() {
+			apply(Overrides{})
+		}).
+		AddButton("Cancel", back)
+	form.SetTitle(" [::b]" + ps.conf.Glyphs().Settings + "Overrides of " + pkg.ID + " (" + pkg.Installation + ") ")
+
+	ps.showDialogForm(form)
+	ps.flexRight.AddItem(ps.tableDetails, 0, 1, false)
+	ps.selectedPackage = nil
+	drawEffective()
+}
@@ -0,0 +1,95 @@
+package flatseek
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseOverrides(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string // args
+	}{
+		{
+			name: "none",
+			data: "",
+			want: []string{},
+		},
+		{
+			name: "context",
+			data: "[Context]\nshared=!network;\nsockets=wayland;!x11;\ndevices=!all;\nfeatures=devel;\n" +
+				"filesystems=~/Games:ro;!home;\npersistent=.local;\n",
+			want: []string{"--unshare=network", "--socket=wayland", "--nosocket=x11", "--nodevice=all", "--allow=devel",
+				"--filesystem=~/Games:ro", "--nofilesystem=home", "--persist=.local"},
+		},
+		{
+			name: "bus names",
+			data: "[Session Bus Policy]\norg.freedesktop.Notifications=talk\norg.example.Own=own\norg.example.See=see\n\n" +
+				"[System Bus Policy]\norg.freedesktop.login1=none\norg.freedesktop.UPower=talk\n",
+			want: []string{"--own-name=org.example.Own", "--talk-name=org.freedesktop.Notifications",
+				"--system-talk-name=org.freedesktop.UPower", "--system-no-talk-name=org.freedesktop.login1"},
+		},
+		{
+			name: "policies and environment",
+			data: "[Context]\nunset-environment=DEBUG;\n\n[Environment]\nLANG=C\n\n[Policy org.example]\nkeys=a;!b;\n",
+			want: []string{"--remove-policy=org.example.keys=b", "--add-policy=org.example.keys=a",
+				"--unset-env=DEBUG", "--env=LANG=C"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseOverrides(tt.data).args(); !slices.Equal(got, tt.want) {
+				t.Errorf("parseOverrides().args() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithOverrides(t *testing.T) {
+	base := Permissions{
+		Shared:      []string{"network", "ipc"},
+		Sockets:     []string{"x11", "wayland"},
+		Filesystems: []string{"home", "xdg-download"},
+		SessionTalk: []string{"org.freedesktop.Notifications"},
+		SystemTalk:  []string{"org.freedesktop.login1"},
+		Environment: []string{"LANG=C"},
+	}
+	tests := []struct {
+		name      string
+		overrides Overrides
+		field     func(Permissions) []string
+		want      []string
+	}{
+		{"unchanged", Overrides{}, func(p Permissions) []string { return p.Sockets }, []string{"x11", "wayland"}},
+		{"revoke", Overrides{Shared: []string{"!network"}}, func(p Permissions) []string { return p.Shared }, []string{"ipc"}},
+		{"grant", Overrides{Devices: []string{"dri"}}, func(p Permissions) []string { return p.Devices }, []string{"dri"}},
+		{"feature", Overrides{Features: []string{"devel"}}, func(p Permissions) []string { return p.Features }, []string{"devel"}},
+		{
+			"filesystem mode", Overrides{Filesystems: []string{"home:ro"}},
+			func(p Permissions) []string { return p.Filesystems }, []string{"xdg-download", "home:ro"},
+		},
+		{
+			"bus name no longer talked to", Overrides{SessionBus: []string{"org.freedesktop.Notifications=none"}},
+			func(p Permissions) []string { return p.SessionTalk }, []string{},
+		},
+		{
+			"bus name owned", Overrides{SystemBus: []string{"org.freedesktop.login1=own"}},
+			func(p Permissions) []string { return append(p.SystemTalk, p.SystemOwn...) }, []string{"org.freedesktop.login1"},
+		},
+		{
+			"environment", Overrides{Environment: []string{"LANG=de_DE.UTF-8", "!LANG", "DEBUG=1"}},
+			func(p Permissions) []string { return p.Environment }, []string{"DEBUG=1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.field(base.withOverrides(tt.overrides)); !slices.Equal(got, tt.want) {
+				t.Errorf("withOverrides() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if !slices.Equal(base.Shared, []string{"network", "ipc"}) {
+		t.Errorf("withOverrides() changed the permissions it was applied to: %v", base.Shared)
+	}
+}
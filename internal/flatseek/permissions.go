@@ -0,0 +1,135 @@
+package flatseek
+
+import (
+	"bufio"
+	"sort"
+	"strings"
+)
+
+// keyFile is a parsed GLib key file like flatpak's metadata or overrides, grouped by section
+type keyFile map[string]map[string]string
+
+// parses a GLib key file; comments and lines outside of a group are skipped
+func parseKeyFile(data string) keyFile {
+	kf := keyFile{}
+	group := ""
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			group = line[1 : len(line)-1]
+			if _, ok := kf[group]; !ok {
+				kf[group] = map[string]string{}
+			}
+			continue
+		}
+		k, v, found := strings.Cut(line, "=")
+		if group == "" || !found {
+			continue
+		}
+		kf[group][strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return kf
+}
+
+// returns the values of a list entry ("a;b;c;")
+func (kf keyFile) list(group, key string) []string {
+	values := []string{}
+	for _, v := range strings.Split(kf[group][key], ";") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// returns the keys of a group having the given value, sorted
+func (kf keyFile) keysWithValue(group, value string) []string {
+	keys := []string{}
+	for k, v := range kf[group] {
+		if v == value {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Permissions is the sandbox scope of an app as declared in its metadata
+type Permissions struct {
+	Shared      []string
+	Sockets     []string
+	Devices     []string
+	Features    []string
+	Filesystems []string
+	SessionTalk []string
+	SessionOwn  []string
+	SystemTalk  []string
+	SystemOwn   []string
+	Environment []string
+}
+
+// extracts the permissions from the [Context], bus policy and [Environment] groups of a metadata file
+func parsePermissions(metadata string) Permissions {
+	kf := parseKeyFile(metadata)
+
+	env := []string{}
+	for k, v := range kf["Environment"] {
+		env = append(env, k+"="+v)
+	}
+	sort.Strings(env)
+
+	return Permissions{
+		Shared:      kf.list("Context", "shared"),
+		Sockets:     kf.list("Context", "sockets"),
+		Devices:     kf.list("Context", "devices"),
+		Features:    kf.list("Context", "features"),
+		Filesystems: kf.list("Context", "filesystems"),
+		SessionTalk: kf.keysWithValue("Session Bus Policy", "talk"),
+		SessionOwn:  kf.keysWithValue("Session Bus Policy", "own"),
+		SystemTalk:  kf.keysWithValue("System Bus Policy", "talk"),
+		SystemOwn:   kf.keysWithValue("System Bus Policy", "own"),
+		Environment: env,
+	}
+}
+
+// permissionField is a named list of permissions for display
+type permissionField struct {
+	name   string
+	values []string
+}
+
+// returns the permissions in the order we display them
+func (p Permissions) fields() []permissionField {
+	return []permissionField{
+		{"Shared", p.Shared},
+		{"Sockets", p.Sockets},
+		{"Devices", p.Devices},
+		{"Features", p.Features},
+		{"Filesystems", p.Filesystems},
+		{"Session bus talk", p.SessionTalk},
+		{"Session bus own", p.SessionOwn},
+		{"System bus talk", p.SystemTalk},
+		{"System bus own", p.SystemOwn},
+		{"Environment", p.Environment},
+	}
+}
+
+// checks if a permission grants access beyond the sandbox which needs a closer look
+func isDangerousPermission(field, value string) bool {
+	switch field {
+	case "Sockets":
+		return value == "x11" || value == "session-bus" || value == "system-bus"
+	case "Devices":
+		return value == "all"
+	case "Filesystems":
+		fs := strings.TrimSuffix(strings.TrimSuffix(value, ":ro"), ":rw")
+		return fs == "host" || fs == "host-os" || fs == "host-etc" || fs == "home" || fs == "~"
+	case "Session bus talk", "Session bus own":
+		return value == "*" || strings.HasPrefix(value, "org.freedesktop.Flatpak")
+	}
+	return false
+}
@@ -0,0 +1,304 @@
+package flatseek
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/XnLogicaL/flatseek/internal/util"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// displays the list of remotes of our installations
+func (ps *UI) displayRemotes() {
+	ps.tableDetails.Clear().
+		SetTitle(" [::b]Retrieving remotes... ")
+
+	go func() {
+		ps.locker.Lock()
+		ps.startSpinner()
+		defer func() {
+			ps.locker.Unlock()
+			ps.stopSpinner()
+		}()
+
+		remotes, err := ps.backend.Remotes()
+		ps.app.QueueUpdateDraw(func() {
+			if err != nil {
+				ps.tableDetails.SetTitle(" [::b]Error ")
+				ps.displayMessage(err.Error(), true)
+				return
+			}
+			ps.drawRemotes(remotes)
+		})
+	}()
+}
+
+// draws the list of remotes
+func (ps *UI) drawRemotes(remotes []Remote) {
+	ps.tableDetails.Clear().
+		SetTitle(" [::b]" + ps.conf.Glyphs().Package + "Remotes ")
+
+	// remove "Latest news" if they were shown previously
+	if ps.flexRight.GetItemCount() == 2 {
+		ps.flexRight.RemoveItem(ps.flexRight.GetItem(1))
+	}
+
+	// header
+	columns := []string{"Remote  ", "Installation  ", "Status  ", "Priority  ", "AppStream  ", "Filter  ", "Title  ", "URL"}
+	for i, col := range columns {
+		ps.tableDetails.SetCell(0, i, &tview.TableCell{
+			Text:            col,
+			Color:           ps.conf.Colors().PackagelistHeader,
+			BackgroundColor: ps.conf.Colors().DefaultBackground,
+		})
+	}
+
+	// lines
+	r := 2
+	for _, remote := range remotes {
+		remote := remote
+		status := "enabled"
+		color := ps.conf.Colors().PackagelistSourceRepository
+		if remote.Disabled {
+			status = "disabled"
+			color = ps.conf.Colors().PackagelistHeader
+		}
+		values := []string{
+			"[::b]" + remote.Name,
+			remote.Installation,
+			status,
+			strconv.Itoa(remote.Priority),
+			formatAge(remote.AppstreamUpdated),
+			remote.Filter,
+			remote.Title,
+			remote.URL,
+		}
+		for i, v := range values {
+			cell := &tview.TableCell{
+				Text:            v,
+				Color:           tcell.ColorWhite,
+				BackgroundColor: ps.conf.Colors().DefaultBackground,
+			}
+			switch i {
+			case 0:
+				cell.SetTextColor(ps.conf.Colors().Accent).
+					SetClickedFunc(func() bool {
+						ps.drawRemoteInfo(remote)
+						return true
+					})
+			case 2:
+				cell.SetTextColor(color)
+			}
+			ps.tableDetails.SetCell(r, i, cell)
+		}
+		r++
+	}
+
+	// no remotes message and add button
+	r++
+	if len(remotes) == 0 {
+		ps.tableDetails.SetCell(r, 0, &tview.TableCell{
+			Text:            "No remotes configured",
+			Color:           ps.conf.Colors().PackagelistHeader,
+			BackgroundColor: ps.conf.Colors().DefaultBackground,
+		})
+		r += 2
+	}
+	ps.tableDetails.SetCell(r, 0, &tview.TableCell{
+		Text:            " [::b]Add remote",
+		Align:           tview.AlignCenter,
+		Color:           ps.conf.Colors().SettingsFieldText,
+		BackgroundColor: ps.conf.Colors().SearchBar,
+		Clicked: func() bool {
+			ps.displayAddRemote()
+			return true
+		},
+	})
+
+	// set nil to avoid printing package details when resizing
+	ps.selectedPackage = nil
+	ps.tableDetails.ScrollToBeginning()
+}
+
+// draws the details of a remote along with buttons for our actions
+func (ps *UI) drawRemoteInfo(remote Remote) {
+	ps.tableDetails.Clear().
+		SetTitle(" [::b]" + ps.conf.Glyphs().Package + remote.Name + " (" + remote.Installation + ") ")
+
+	status := "enabled"
+	if remote.Disabled {
+		status = "disabled"
+	}
+	fields := [][]string{
+		{"Title", remote.Title},
+		{"URL", remote.URL},
+		{"Installation", remote.Installation},
+		{"Status", status},
+		{"Priority", strconv.Itoa(remote.Priority)},
+		{"Filter", remote.Filter},
+		{"AppStream updated", formatAge(remote.AppstreamUpdated)},
+	}
+	r := 0
+	for _, f := range fields {
+		ps.tableDetails.SetCell(r, 0, &tview.TableCell{
+			Text:            "[::b]" + f[0],
+			Color:           ps.conf.Colors().Accent,
+			BackgroundColor: ps.conf.Colors().DefaultBackground,
+		}).
+			SetCell(r, 1, &tview.TableCell{
+				Text:            f[1],
+				Color:           tcell.ColorWhite,
+				BackgroundColor: ps.conf.Colors().DefaultBackground,
+			})
+		r++
+	}
+
+	// buttons
+	toggle := "Disable"
+	if remote.Disabled {
+		toggle = "Enable"
+	}
+	buttons := []struct {
+		text    string
+		clicked func()
+	}{
+		{toggle, func() {
+			ps.remoteTransaction(func() error {
+				return ps.backend.SetRemoteEnabled(remote, remote.Disabled)
+			})
+		}},
+		{"Refresh AppStream", func() {
+			ps.remoteTransaction(func() error {
+				return ps.backend.UpdateAppstream(remote)
+			})
+		}},
+		{"Remove", func() {
+			ps.removeRemote(remote)
+		}},
+		{"Back", func() {
+			ps.displayRemotes()
+		}},
+	}
+	r++
+	for _, b := range buttons {
+		b := b
+		ps.tableDetails.SetCell(r, 0, &tview.TableCell{
+			Text:            " [::b]" + b.text,
+			Align:           tview.AlignCenter,
+			Color:           ps.conf.Colors().SettingsFieldText,
+			BackgroundColor: ps.conf.Colors().SearchBar,
+			Clicked: func() bool {
+				b.clicked()
+				return true
+			},
+		})
+		r += 2
+	}
+	ps.tableDetails.ScrollToBeginning()
+}
+
+// asks for confirmation and removes a remote, listing the refs which would be orphaned
+func (ps *UI) removeRemote(remote Remote) {
+	go func() {
+		ps.locker.Lock()
+		ps.startSpinner()
+		defer func() {
+			ps.locker.Unlock()
+			ps.stopSpinner()
+		}()
+
+		err := ps.loadInstalledRefs()
+		ps.app.QueueUpdateDraw(func() {
+			if err != nil {
+				ps.displayMessage(err.Error(), true)
+				return
+			}
+			ps.askRemoveRemote(remote, ps.installedFromRemote(remote))
+		})
+	}()
+}
+
+// asks if a remote should be removed, listing the installed refs which would be orphaned
+func (ps *UI) askRemoveRemote(remote Remote, orphaned []Package) {
+	text := fmt.Sprintf("Remove remote %s from the %s installation?", remote.Name, remote.Installation)
+	if len(orphaned) > 0 {
+		refs := []string{}
+		for _, pkg := range orphaned {
+			refs = append(refs, pkg.Ref.String())
+		}
+		text += "\n\nThe following installed refs will be orphaned:\n" + strings.Join(refs, "\n")
+	}
+
+	ask := tview.NewModal().
+		AddButtons([]string{"Yes", "No"}).
+		SetText(text).
+		SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+			ps.app.SetRoot(ps.flexRoot, true)
+			if buttonIndex == 0 {
+				ps.remoteTransaction(func() error {
+					return ps.backend.RemoveRemote(remote, len(orphaned) > 0)
+				})
+			}
+		})
+
+	ps.app.SetRoot(ask, true)
+}
+
+// runs an operation on a remote, refreshes our data and shows the list of remotes afterwards
+func (ps *UI) remoteTransaction(f func() error) {
+	ps.runTransaction(f)
+	ps.refreshInstalledState()
+	ps.displayRemotes()
+}
+
+// displays a form to add a remote from a URL or .flatpakrepo file
+func (ps *UI) displayAddRemote() {
+	installations := installationIDs()
+	iIndex := util.IndexOf(installations, ps.installation)
+	if iIndex < 0 {
+		iIndex = 0
+	}
+	form := tview.NewForm().
+		AddInputField("Name: ", "", 30, nil, nil).
+		AddInputField("URL or .flatpakrepo file: ", "", 50, nil, nil).
+		AddDropDown("Installation: ", installations, iIndex, nil)
+	form.AddButton("Add", func() {
+		name := form.GetFormItemByLabel("Name: ").(*tview.InputField).GetText()
+		location := form.GetFormItemByLabel("URL or .flatpakrepo file: ").(*tview.InputField).GetText()
+		_, installation := form.GetFormItemByLabel("Installation: ").(*tview.DropDown).GetCurrentOption()
+		if name == "" || location == "" {
+			ps.displayMessage("Name and URL / file are required", true)
+			return
+		}
+		ps.closeDialogForm()
+		ps.remoteTransaction(func() error {
+			return ps.backend.AddRemote(name, location, installation)
+		})
+	}).
+		AddButton("Cancel", func() {
+			ps.closeDialogForm()
+		})
+	form.SetTitle(" [::b]" + ps.conf.Glyphs().Settings + "Add remote ")
+
+	ps.showDialogForm(form)
+}
+
+// formats the time passed since t
+func formatAge(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%d min ago", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%d hours ago", int(d.Hours()))
+	}
+	return fmt.Sprintf("%d days ago", int(d.Hours()/24))
+}
@@ -29,6 +29,7 @@ func (ps *UI) createComponents() {
 	ps.formSettings = tview.NewForm()
 	ps.textMessage = tview.NewTextView()
 	ps.textPkgbuild = tview.NewTextView()
+	ps.treeDeps = tview.NewTreeView()
 	ps.tableNews = tview.NewTable()
 
 	// component config
@@ -43,6 +44,8 @@ func (ps *UI) createComponents() {
 		SetFocusFunc(func() {
 			if ps.flexRight.GetItem(0) == ps.textPkgbuild {
 				ps.app.SetFocus(ps.textPkgbuild)
+			} else if ps.flexRight.GetItem(0) == ps.treeDeps {
+				ps.app.SetFocus(ps.treeDeps)
 			} else if !ps.tableDetailsMore {
 				ps.app.SetFocus(ps.tablePackages)
 			}
@@ -69,6 +72,10 @@ func (ps *UI) createComponents() {
 		SetTitleAlign(tview.AlignLeft).
 		SetBorderPadding(1, 1, 1, 1)
 	ps.pkgbuildWriter = tview.ANSIWriter(ps.textPkgbuild)
+	ps.treeDeps.SetGraphicsColor(tcell.ColorGray).
+		SetBorder(true).
+		SetTitleAlign(tview.AlignLeft).
+		SetBorderPadding(1, 1, 1, 1)
 	ps.tableNews.SetSelectable(false, false).
 		SetFocusFunc(func() {
 			ps.app.SetFocus(ps.inputSearch)
@@ -113,18 +120,18 @@ func (ps *UI) applyColors() {
 	ps.inputSearch.SetFieldBackgroundColor(ps.conf.Colors().SearchBar).SetBackgroundColor(ps.conf.Colors().DefaultBackground)
 	ps.inputSearch.SetAutocompleteStyles(ps.conf.Colors().SettingsDropdownNotSelected, tcell.StyleDefault, tcell.StyleDefault.Reverse(true))
 	ps.textPkgbuild.SetTitleColor(ps.conf.Colors().Title).SetBackgroundColor(ps.conf.Colors().DefaultBackground)
+	ps.treeDeps.SetTitleColor(ps.conf.Colors().Title).SetBackgroundColor(ps.conf.Colors().DefaultBackground)
 	ps.tableNews.SetTitleColor(ps.conf.Colors().Title).SetBackgroundColor(ps.conf.Colors().DefaultBackground)
 	ps.tablePackages.SetBackgroundColor(ps.conf.Colors().DefaultBackground)
 	ps.tablePackages.SetSelectedStyle(tcell.StyleDefault.Reverse(true))
 	ps.spinner.SetBackgroundColor(ps.conf.Colors().DefaultBackground)
 
 	// settings form
-	ps.formSettings.SetFieldBackgroundColor(ps.conf.Colors().SettingsFieldBackground).
-		SetFieldTextColor(ps.conf.Colors().SettingsFieldText).
-		SetButtonBackgroundColor(ps.conf.Colors().SettingsFieldBackground).
-		SetButtonTextColor(ps.conf.Colors().SettingsFieldText).
-		SetLabelColor(ps.conf.Colors().SettingsFieldLabel)
+	ps.applyFormColors(ps.formSettings)
 	ps.applyDropDownColors()
+	if ps.formDialog != nil {
+		ps.applyFormColors(ps.formDialog)
+	}
 
 	// package list
 	ps.drawPackageListHeader(ps.conf.PackageColumnWidth)
@@ -143,9 +150,11 @@ func (ps *UI) applyColors() {
 		c.SetBackgroundColor(ps.conf.Colors().DefaultBackground)
 
 		// Installed
-		c = ps.tablePackages.GetCell(i, 2)
+		c = ps.tablePackages.GetCell(i, 5)
 		c.SetTextColor(ps.conf.Colors().DefaultBackground)
-		c.SetText(ps.getInstalledStateText(c.Reference.(bool)))
+		if ref, ok := c.Reference.(bool); ok {
+			c.SetText(ps.getInstalledStateText(ref))
+		}
 	}
 
 	// details
@@ -154,9 +163,46 @@ func (ps *UI) applyColors() {
 	}
 }
 
+// apply colors to a form and its drop-downs
+func (ps *UI) applyFormColors(form *tview.Form) {
+	form.SetTitleColor(ps.conf.Colors().Title).SetBackgroundColor(ps.conf.Colors().DefaultBackground)
+	form.SetFieldBackgroundColor(ps.conf.Colors().SettingsFieldBackground).
+		SetFieldTextColor(ps.conf.Colors().SettingsFieldText).
+		SetButtonBackgroundColor(ps.conf.Colors().SettingsFieldBackground).
+		SetButtonTextColor(ps.conf.Colors().SettingsFieldText).
+		SetLabelColor(ps.conf.Colors().SettingsFieldLabel)
+	for i := 0; i < form.GetFormItemCount(); i++ {
+		if dd, ok := form.GetFormItem(i).(*tview.DropDown); ok {
+			dd.SetListStyles(tcell.StyleDefault.Background(ps.conf.Colors().SettingsDropdownNotSelected).Foreground(ps.conf.Colors().SettingsFieldText),
+				tcell.StyleDefault.Background(ps.conf.Colors().SettingsFieldText).Foreground(ps.conf.Colors().SettingsDropdownNotSelected))
+		}
+	}
+}
+
+// shows a form in the right box, e.g. to ask for the input of an action
+func (ps *UI) showDialogForm(form *tview.Form) {
+	ps.formDialog = form
+	form.SetItemPadding(0).
+		SetBorder(true).
+		SetTitleAlign(tview.AlignLeft)
+	ps.applyFormColors(form)
+
+	ps.flexRight.Clear()
+	ps.flexRight.AddItem(form, 0, 1, false)
+	ps.app.SetFocus(form)
+}
+
+// closes the dialog form and shows the details box again
+func (ps *UI) closeDialogForm() {
+	ps.formDialog = nil
+	ps.flexRight.Clear()
+	ps.flexRight.AddItem(ps.tableDetails, 0, 1, false)
+	ps.app.SetFocus(ps.tablePackages)
+}
+
 // apply drop-down colors
 func (ps *UI) applyDropDownColors() {
-	for _, title := range []string{"Search mode: ", "Search by: ", "Color scheme: ", "Border style: ", "Glyph style: "} {
+	for _, title := range []string{"Search mode: ", "Search by: ", "Installation: ", "Color scheme: ", "Border style: ", "Glyph style: "} {
 		if dd, ok := ps.formSettings.GetFormItemByLabel(title).(*tview.DropDown); ok {
 			dd.SetListStyles(tcell.StyleDefault.Background(ps.conf.Colors().SettingsDropdownNotSelected).Foreground(ps.conf.Colors().SettingsFieldText),
 				tcell.StyleDefault.Background(ps.conf.Colors().SettingsFieldText).Foreground(ps.conf.Colors().SettingsDropdownNotSelected))
@@ -197,7 +243,7 @@ func (ps *UI) applyGlyphStyle() {
 
 	// package list
 	for i := 1; i < ps.tablePackages.GetRowCount(); i++ {
-		c := ps.tablePackages.GetCell(i, 2)
+		c := ps.tablePackages.GetCell(i, 5)
 		if ref, ok := c.Reference.(bool); ok {
 			c.SetText(ps.getInstalledStateText(ref))
 		}
@@ -219,10 +265,18 @@ func (ps *UI) setupKeyBindings() {
 	ps.app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		settingsVisible := ps.flexRight.GetItem(0) == ps.formSettings
 		pkgbuildVisible := ps.flexRight.GetItem(0) == ps.textPkgbuild
+		depsVisible := ps.flexRight.GetItem(0) == ps.treeDeps
+		dialogVisible := ps.formDialog != nil && ps.flexRight.GetItem(0) == ps.formDialog
+
+		// ESC - Close dialog
+		if event.Key() == tcell.KeyEscape && dialogVisible {
+			ps.closeDialogForm()
+			return nil
+		}
 
 		// CTRL+Q / ESC - Quit
 		if event.Key() == tcell.KeyCtrlQ ||
-			(event.Key() == tcell.KeyEscape && !settingsVisible && !pkgbuildVisible && !ps.conf.EnableAutoSuggest) {
+			(event.Key() == tcell.KeyEscape && !settingsVisible && !pkgbuildVisible && !depsVisible && !ps.conf.EnableAutoSuggest) {
 			if !ps.settingsChanged {
 				if ps.conf.SaveWindowLayout {
 					ps.conf.LeftProportion = ps.leftProportion
@@ -289,18 +343,53 @@ func (ps *UI) setupKeyBindings() {
 		if event.Key() == tcell.KeyCtrlW {
 			ps.cacheSearch.Flush()
 			ps.cacheInfo.Flush()
+			ps.cachePkgbuild.Flush()
 			return nil
 		}
 
-		// CTRL+O - Open URL for selected package
+		// CTRL+O - Open homepage of selected package
 		if event.Key() == tcell.KeyCtrlO && ps.selectedPackage != nil {
-			exec.Command("xdg-open", "youtube.com").Start()
+			if ps.selectedPackage.Details == nil || ps.selectedPackage.Details.URLs["homepage"] == "" {
+				ps.displayMessage("No homepage known for "+ps.selectedPackage.ID, true)
+				return nil
+			}
+			exec.Command("xdg-open", ps.selectedPackage.Details.URLs["homepage"]).Start()
+			return nil
+		}
+
+		// CTRL+P - Show metadata / manifest of selected package
+		if event.Key() == tcell.KeyCtrlP {
+			if pkgbuildVisible {
+				ps.closeSource()
+			} else if !dialogVisible {
+				ps.displaySource()
+			}
+			return nil
+		}
+
+		// CTRL+D - Show dependency tree of selected package
+		if event.Key() == tcell.KeyCtrlD {
+			if depsVisible {
+				ps.closeDependencies()
+			} else if !dialogVisible {
+				ps.displayDependencies()
+			}
+			return nil
+		}
+
+		// CTRL+K - Clean up unused runtimes and extensions
+		if event.Key() == tcell.KeyCtrlK {
+			if pkgbuildVisible || depsVisible || settingsVisible || dialogVisible {
+				ps.flexRight.Clear()
+				ps.flexRight.AddItem(ps.tableDetails, 0, 1, false)
+			}
+			ps.displayCleanup()
 			return nil
 		}
 
 		// CTRL+G - Upgradable packages
 		if event.Key() == tcell.KeyCtrlG {
-			if pkgbuildVisible || settingsVisible {
+			if pkgbuildVisible || depsVisible || settingsVisible || dialogVisible {
 				ps.flexRight.Clear()
 				ps.flexRight.AddItem(ps.tableDetails, 0, 1, false)
 			}
@@ -310,7 +399,7 @@ func (ps *UI) setupKeyBindings() {
 
 		// CTRL+L - Locally installed packages
 		if event.Key() == tcell.KeyCtrlL {
-			if pkgbuildVisible {
+			if pkgbuildVisible || depsVisible || dialogVisible {
 				ps.flexRight.Clear()
 				ps.flexRight.AddItem(ps.tableDetails, 0, 1, false)
 			}
@@ -318,6 +407,50 @@ func (ps *UI) setupKeyBindings() {
 			return nil
 		}
 
+		// CTRL+R - Remotes
+		if event.Key() == tcell.KeyCtrlR {
+			if pkgbuildVisible || depsVisible || settingsVisible || dialogVisible {
+				ps.flexRight.Clear()
+				ps.flexRight.AddItem(ps.tableDetails, 0, 1, false)
+			}
+			ps.displayRemotes()
+			return nil
+		}
+
+		// CTRL+T - Running instances
+		if event.Key() == tcell.KeyCtrlT {
+			if pkgbuildVisible || depsVisible || settingsVisible || dialogVisible {
+				ps.flexRight.Clear()
+				ps.flexRight.AddItem(ps.tableDetails, 0, 1, false)
+			}
+			ps.displayInstances()
+			return nil
+		}
+
+		// CTRL+X - Export / import installed apps
+		if event.Key() == tcell.KeyCtrlX {
+			if !dialogVisible {
+				ps.displayExportImport()
+			}
+			return nil
+		}
+
+		// CTRL+F - Open a bundle, flatpakref or flatpakrepo file
+		if event.Key() == tcell.KeyCtrlF {
+			if !dialogVisible {
+				ps.displayOpenBundle()
+			}
+			return nil
+		}
+
+		// CTRL+E - Edit permission overrides of the selected app
+		if event.Key() == tcell.KeyCtrlE {
+			if !dialogVisible {
+				ps.displayOverrides()
+			}
+			return nil
+		}
+
 		// Shift+Left - decrease size of left container
 		if event.Key() == tcell.KeyLeft && event.Modifiers() == tcell.ModShift {
 			if ps.leftProportion != 1 {
@@ -396,7 +529,7 @@ func (ps *UI) setupKeyBindings() {
 			if itemRight == ps.formSettings {
 				ps.app.SetFocus(ps.formSettings.GetFormItem(0))
 			} else if (itemRight == ps.tableDetails && ps.tableDetailsMore) ||
-				(itemRight == ps.formSettings || itemRight == ps.textPkgbuild) {
+				(itemRight == ps.formSettings || itemRight == ps.textPkgbuild || itemRight == ps.treeDeps) {
 				ps.app.SetFocus(itemRight)
 			} else {
 				ps.app.SetFocus(ps.inputSearch)
@@ -431,7 +564,7 @@ func (ps *UI) setupKeyBindings() {
 		}
 
 		// sorting keys
-		if util.SliceContains([]rune{'N', 'S', 'I', 'M', 'P'}, event.Rune()) {
+		if util.SliceContains([]rune{'N', 'S', 'Z', 'I', 'M', 'P'}, event.Rune()) {
 			ps.sortAndRedrawPackageList(event.Rune())
 			return nil
 		}
@@ -447,8 +580,33 @@ func (ps *UI) setupKeyBindings() {
 		ps.tablePackages.SetTitle(fmt.Sprintf(" (%d/%d) ", row, ps.tablePackages.GetRowCount()-1))
 	})
 
-	// PKGBUILD
+	// Metadata / manifest viewer
 	ps.textPkgbuild.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		// "/", "n", "N" and ESC
+		if event = ps.sourceInputCapture(event); event == nil {
+			return nil
+		}
+		// CTRL+Left
+		if event.Key() == tcell.KeyLeft && event.Modifiers() == tcell.ModCtrl {
+			ps.app.SetFocus(ps.tablePackages)
+			return nil
+		}
+		// TAB
+		if event.Key() == tcell.KeyTAB {
+			ps.app.SetFocus(ps.inputSearch)
+			return nil
+		}
+
+		return event
+	})
+
+	// Dependency tree
+	ps.treeDeps.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		// ESC
+		if event.Key() == tcell.KeyEscape {
+			ps.closeDependencies()
+			return nil
+		}
 		// CTRL+Left
 		if event.Key() == tcell.KeyLeft && event.Modifiers() == tcell.ModCtrl {
 			ps.app.SetFocus(ps.tablePackages)
@@ -490,7 +648,7 @@ func (ps *UI) setupSettingsForm() {
 
 	// Defaults button clicked
 	ps.formSettings.AddButton("Defaults", func() {
-		ps.conf = config.Defaults()
+		*ps.conf = *config.Defaults()
 		ps.drawSettingsFields(ps.conf.DisableAur, ps.conf.DisableCache, ps.conf.AurUseDifferentCommands, ps.conf.ShowPkgbuildInternally, ps.conf.DisableNewsFeed)
 		ps.saveSettings(true)
 	})
@@ -502,6 +660,7 @@ func (ps *UI) setupSettingsForm() {
 // read settings from from and saves to config file
 func (ps *UI) saveSettings(defaults bool) {
 	var err error
+	installation, selected := ps.installation, ps.installation
 	for i := 0; i < ps.formSettings.GetFormItemCount(); i++ {
 		item := ps.formSettings.GetFormItem(i)
 		if input, ok := item.(*tview.InputField); ok {
@@ -571,6 +730,15 @@ func (ps *UI) saveSettings(defaults bool) {
 				ps.conf.SearchMode = opt
 			case "Search by: ":
 				ps.conf.SearchBy = opt
+			case "Installation: ":
+				if opt == "All" {
+					opt = ""
+				}
+				// only saved when changed, the one given as argument is kept out of our config
+				if opt != installation {
+					ps.conf.Installation = opt
+					selected = opt
+				}
 			case "Color scheme: ":
 				ps.conf.ColorScheme = opt
 			case "Border style: ":
@@ -623,4 +791,12 @@ func (ps *UI) saveSettings(defaults bool) {
 	if ps.conf.DisableCache {
 		ps.cacheInfo.Flush()
 	}
+	if defaults {
+		selected = ps.conf.Installation
+	}
+	if selected != installation {
+		ps.installation = selected
+		ps.backend.SetInstallation(ps.installation)
+		ps.refreshInstalledState()
+	}
 }
@@ -0,0 +1,345 @@
+package flatseek
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/XnLogicaL/flatseek/internal/util"
+)
+
+// stateVersion is the version of our export file format
+const stateVersion = 1
+
+// State is a set of remotes and apps as exported from a machine, or the desired state for apply.
+// Masks are optional; with Prune, apps and masks which are not part of the state are removed by apply
+type State struct {
+	Version int
+	Remotes []StateRemote
+	Apps    []StateApp
+	Masks   []Mask `json:",omitempty"`
+	Prune   bool   `json:",omitempty"`
+}
+
+// StateRemote is a remote of an exported state
+type StateRemote struct {
+	Name         string
+	URL          string
+	Installation string
+}
+
+// StateApp is an installed app of an exported state; overrides are optional
+type StateApp struct {
+	Ref          string
+	Remote       string
+	Installation string
+	Overrides    *Overrides `json:",omitempty"`
+}
+
+// StateDiff are the differences between a state and the current machine
+type StateDiff struct {
+	MissingRemotes   []StateRemote
+	ChangedRemotes   []StateRemote
+	MissingApps      []StateApp
+	ExtraApps        []Package
+	ChangedOverrides []StateApp
+	MissingMasks     []Mask
+	ExtraMasks       []Mask
+}
+
+// empty checks if the machine matches the state
+func (d StateDiff) empty() bool {
+	return len(d.MissingRemotes) == 0 && len(d.ChangedRemotes) == 0 && len(d.MissingApps) == 0 && len(d.ExtraApps) == 0 &&
+		len(d.ChangedOverrides) == 0 && len(d.MissingMasks) == 0 && len(d.ExtraMasks) == 0
+}
+
+// creates a state from the remotes and installed apps of a backend
+func exportState(b Backend, withOverrides bool) (State, error) {
+	state := State{Version: stateVersion, Remotes: []StateRemote{}, Apps: []StateApp{}}
+
+	remotes, err := b.Remotes()
+	if err != nil {
+		return state, err
+	}
+	for _, r := range remotes {
+		state.Remotes = append(state.Remotes, StateRemote{Name: r.Name, URL: r.URL, Installation: r.Installation})
+	}
+
+	installed, err := b.ListInstalled()
+	if err != nil {
+		return state, err
+	}
+	for _, pkg := range installed {
+		if pkg.Kind != "app" {
+			continue
+		}
+		app := StateApp{Ref: pkg.Ref.String(), Remote: pkg.Remote, Installation: pkg.Installation}
+		if withOverrides {
+			o, err := b.Overrides(pkg)
+			if err != nil {
+				return state, err
+			}
+			if len(o.args()) > 0 {
+				app.Overrides = &o
+			}
+		}
+		state.Apps = append(state.Apps, app)
+	}
+	sort.Slice(state.Apps, func(i, j int) bool {
+		return state.Apps[i].Ref < state.Apps[j].Ref
+	})
+	return state, nil
+}
+
+// writes a state to a JSON file
+func saveState(file string, state State) error {
+	b, err := json.MarshalIndent(state, "", "	")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, b, 0644)
+}
+
+// reads a state from a JSON file. if we are limited to an installation, remotes, apps and masks must be in it;
+// those without installation are taken to be in it, or in "system" if we are not limited
+func loadState(file, installation string) (State, error) {
+	state := State{}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return state, err
+	}
+	if err = json.Unmarshal(b, &state); err != nil {
+		return state, fmt.Errorf("%s: %w", file, err)
+	}
+	if state.Version > stateVersion {
+		return state, fmt.Errorf("%s: unsupported version %d", file, state.Version)
+	}
+	for _, app := range state.Apps {
+		if _, err := ParseRef(app.Ref); err != nil {
+			return state, fmt.Errorf("%s: %w", file, err)
+		}
+	}
+
+	defaultInstallation := installation
+	if defaultInstallation == "" {
+		defaultInstallation = "system"
+	}
+	installations := installationIDs()
+	check := func(i *string) error {
+		if *i == "" {
+			*i = defaultInstallation
+		}
+		if util.IndexOf(installations, *i) < 0 {
+			return fmt.Errorf("%s: unknown installation: %s", file, *i)
+		}
+		// we would not see what is installed there and always take it to be missing
+		if installation != "" && *i != installation {
+			return fmt.Errorf("%s: installation %s is not the selected one: %s", file, *i, installation)
+		}
+		return nil
+	}
+	for i := range state.Remotes {
+		if err := check(&state.Remotes[i].Installation); err != nil {
+			return state, err
+		}
+	}
+	for i := range state.Apps {
+		if err := check(&state.Apps[i].Installation); err != nil {
+			return state, err
+		}
+	}
+	for i := range state.Masks {
+		if err := check(&state.Masks[i].Installation); err != nil {
+			return state, err
+		}
+	}
+	return state, nil
+}
+
+// checks if an installed package is an app of a state; only the parts of the ref the state
+// names are compared, e.g. "org.x.Y" is any branch of the app
+func (a StateApp) matches(pkg Package) bool {
+	ref, err := ParseRef(a.Ref)
+	if err != nil || pkg.Installation != a.Installation {
+		return false
+	}
+	for _, p := range [][2]string{
+		{ref.Kind, pkg.Kind},
+		{ref.ID, pkg.ID},
+		{ref.Arch, pkg.Arch},
+		{ref.Branch, pkg.Branch},
+	} {
+		if p[0] != "" && p[0] != p[1] {
+			return false
+		}
+	}
+	return true
+}
+
+// compares a state with the remotes and installed apps of a backend
+func diffState(b Backend, state State) (StateDiff, error) {
+	diff := StateDiff{}
+
+	remotes, err := b.Remotes()
+	if err != nil {
+		return diff, err
+	}
+	for _, want := range state.Remotes {
+		found := false
+		for _, r := range remotes {
+			if r.Name == want.Name && r.Installation == want.Installation {
+				found = true
+				if want.URL != "" && strings.TrimSuffix(r.URL, "/") != strings.TrimSuffix(want.URL, "/") {
+					diff.ChangedRemotes = append(diff.ChangedRemotes, want)
+				}
+				break
+			}
+		}
+		if !found {
+			diff.MissingRemotes = append(diff.MissingRemotes, want)
+		}
+	}
+
+	installed, err := b.ListInstalled()
+	if err != nil {
+		return diff, err
+	}
+	for _, want := range state.Apps {
+		var current *Package
+		for i, pkg := range installed {
+			if want.matches(pkg) {
+				current = &installed[i]
+				break
+			}
+		}
+		if current == nil {
+			diff.MissingApps = append(diff.MissingApps, want)
+			continue
+		}
+		if want.Overrides != nil {
+			o, err := b.Overrides(*current)
+			if err != nil {
+				return diff, err
+			}
+			if strings.Join(o.args(), " ") != strings.Join(want.Overrides.args(), " ") {
+				diff.ChangedOverrides = append(diff.ChangedOverrides, want)
+			}
+		}
+	}
+	for _, pkg := range installed {
+		if pkg.Kind != "app" {
+			continue
+		}
+		found := false
+		for _, want := range state.Apps {
+			if want.matches(pkg) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			diff.ExtraApps = append(diff.ExtraApps, pkg)
+		}
+	}
+
+	// masks are only compared if the state declares them
+	if len(state.Masks) == 0 && !state.Prune {
+		return diff, nil
+	}
+	masks, err := b.Masks()
+	if err != nil {
+		return diff, err
+	}
+	for _, want := range state.Masks {
+		if util.IndexOf(masks, want) < 0 {
+			diff.MissingMasks = append(diff.MissingMasks, want)
+		}
+	}
+	for _, m := range masks {
+		if util.IndexOf(state.Masks, m) < 0 {
+			diff.ExtraMasks = append(diff.ExtraMasks, m)
+		}
+	}
+	return diff, nil
+}
+
+// runs the transactions which bring the machine to a state: missing remotes are added and missing
+// apps installed along with their overrides; extra apps are only removed if requested
+func applyStateDiff(b Backend, diff StateDiff, removeExtra bool) error {
+	for _, r := range diff.MissingRemotes {
+		if err := b.AddRemote(r.Name, r.URL, r.Installation); err != nil {
+			return err
+		}
+	}
+	for _, r := range diff.ChangedRemotes {
+		if err := b.SetRemoteURL(Remote{Name: r.Name, Installation: r.Installation}, r.URL); err != nil {
+			return err
+		}
+	}
+	for _, app := range diff.MissingApps {
+		ref, _ := ParseRef(app.Ref)
+		pkg := Package{Ref: ref, Remote: app.Remote, Installation: app.Installation}
+		if err := b.Install(pkg); err != nil {
+			return err
+		}
+		if app.Overrides != nil {
+			if err := b.SetOverrides(pkg, *app.Overrides); err != nil {
+				return err
+			}
+		}
+	}
+	for _, app := range diff.ChangedOverrides {
+		ref, _ := ParseRef(app.Ref)
+		pkg := Package{Ref: ref, Remote: app.Remote, Installation: app.Installation}
+		if err := b.SetOverrides(pkg, *app.Overrides); err != nil {
+			return err
+		}
+	}
+	for _, m := range diff.MissingMasks {
+		if err := b.AddMask(m); err != nil {
+			return err
+		}
+	}
+	if removeExtra {
+		for _, pkg := range diff.ExtraApps {
+			if err := b.Uninstall(pkg); err != nil {
+				return err
+			}
+		}
+		for _, m := range diff.ExtraMasks {
+			if err := b.RemoveMask(m); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// returns the plan of a diff as lines like "+ install app/org.x.Y/x86_64/stable (user) from flathub"
+func (d StateDiff) plan() []string {
+	lines := []string{}
+	for _, r := range d.MissingRemotes {
+		lines = append(lines, fmt.Sprintf("+ add remote %s (%s) %s", r.Name, r.Installation, r.URL))
+	}
+	for _, r := range d.ChangedRemotes {
+		lines = append(lines, fmt.Sprintf("~ set url of remote %s (%s) to %s", r.Name, r.Installation, r.URL))
+	}
+	for _, app := range d.MissingApps {
+		lines = append(lines, fmt.Sprintf("+ install %s (%s) from %s", app.Ref, app.Installation, app.Remote))
+	}
+	for _, app := range d.ChangedOverrides {
+		lines = append(lines, fmt.Sprintf("~ set overrides of %s (%s): %s", app.Ref, app.Installation, strings.Join(app.Overrides.args(), " ")))
+	}
+	for _, m := range d.MissingMasks {
+		lines = append(lines, fmt.Sprintf("+ add %s %s (%s)", m.kind(), m.Pattern, m.Installation))
+	}
+	for _, pkg := range d.ExtraApps {
+		lines = append(lines, fmt.Sprintf("- uninstall %s (%s)", pkg.Ref.String(), pkg.Installation))
+	}
+	for _, m := range d.ExtraMasks {
+		lines = append(lines, fmt.Sprintf("- remove %s %s (%s)", m.kind(), m.Pattern, m.Installation))
+	}
+	return lines
+}
@@ -0,0 +1,193 @@
+package flatseek
+
+import (
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDiffStatePlan(t *testing.T) {
+	inSync := State{
+		Remotes: []StateRemote{
+			{Name: "flathub", URL: "https://dl.flathub.org/repo", Installation: "system"},
+			{Name: "flathub-beta", URL: "https://dl.flathub.org/beta-repo/", Installation: "user"},
+		},
+		Apps: []StateApp{{Ref: "app/org.gnome.Calculator/x86_64/stable", Remote: "flathub", Installation: "system"}},
+	}
+	tests := []struct {
+		name  string
+		state State
+		want  []string
+	}{
+		{name: "in sync", state: inSync, want: []string{}},
+		{
+			name: "partial refs",
+			state: State{
+				Remotes: inSync.Remotes,
+				Apps: []StateApp{
+					{Ref: "org.gnome.Calculator", Remote: "flathub", Installation: "system"},
+					{Ref: "org.gimp.GIMP//stable", Remote: "flathub", Installation: "system"},
+				},
+			},
+			want: []string{"+ install org.gimp.GIMP//stable (system) from flathub"},
+		},
+		{
+			name: "other branch",
+			state: State{
+				Remotes: inSync.Remotes,
+				Apps:    []StateApp{{Ref: "app/org.gnome.Calculator//beta", Remote: "flathub", Installation: "system"}},
+			},
+			want: []string{
+				"+ install app/org.gnome.Calculator//beta (system) from flathub",
+				"- uninstall app/org.gnome.Calculator/x86_64/stable (system)",
+			},
+		},
+		{
+			name: "remotes",
+			state: State{
+				Remotes: []StateRemote{
+					{Name: "flathub", URL: "https://dl.flathub.org/repo/", Installation: "user"},
+					{Name: "flathub-beta", URL: "https://example.org/beta-repo/", Installation: "user"},
+				},
+				Apps: inSync.Apps,
+			},
+			want: []string{
+				"+ add remote flathub (user) https://dl.flathub.org/repo/",
+				"~ set url of remote flathub-beta (user) to https://example.org/beta-repo/",
+			},
+		},
+		{
+			name: "apps",
+			state: State{
+				Remotes: inSync.Remotes,
+				Apps: []StateApp{
+					{Ref: "app/org.gimp.GIMP/x86_64/stable", Remote: "flathub", Installation: "system"},
+					{Ref: "app/org.gnome.Calculator/x86_64/stable", Remote: "flathub", Installation: "user"},
+				},
+			},
+			want: []string{
+				"+ install app/org.gimp.GIMP/x86_64/stable (system) from flathub",
+				"+ install app/org.gnome.Calculator/x86_64/stable (user) from flathub",
+				"- uninstall app/org.gnome.Calculator/x86_64/stable (system)",
+			},
+		},
+		{
+			name: "overrides",
+			state: State{
+				Remotes: inSync.Remotes,
+				Apps: []StateApp{{
+					Ref: "app/org.gnome.Calculator/x86_64/stable", Remote: "flathub", Installation: "system",
+					Overrides: &Overrides{Sockets: []string{"!x11"}, SessionBus: []string{"org.example.Y=talk"}},
+				}},
+			},
+			want: []string{
+				"~ set overrides of app/org.gnome.Calculator/x86_64/stable (system): --nosocket=x11 --talk-name=org.example.Y",
+			},
+		},
+		{
+			name: "masks",
+			state: State{
+				Remotes: inSync.Remotes,
+				Apps:    inSync.Apps,
+				Masks: []Mask{
+					{Pattern: "org.gimp.*", Installation: "system"},
+					{Pattern: "org.example.*", Installation: "system"},
+				},
+			},
+			want: []string{
+				"+ add mask org.example.* (system)",
+				"- remove pin runtime/org.gnome.Platform/x86_64/45 (system)",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff, err := diffState(newTestBackend(t), tt.state)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := diff.plan(); !slices.Equal(got, tt.want) {
+				t.Errorf("plan() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+			if diff.empty() != (len(tt.want) == 0) {
+				t.Errorf("empty() = %v with plan %v", diff.empty(), tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadState(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         string
+		installation string
+		want         []string // installations of remotes, apps and masks
+		wantErr      bool
+	}{
+		{
+			name: "default installation",
+			data: `{"Version": 1, "Remotes": [{"Name": "flathub", "URL": "https://dl.flathub.org/repo/"}],
+				"Apps": [{"Ref": "app/org.gnome.Calculator/x86_64/stable", "Remote": "flathub"}],
+				"Masks": [{"Pattern": "org.gimp.*"}]}`,
+			installation: "user",
+			want:         []string{"user", "user", "user"},
+		},
+		{
+			name: "system without default",
+			data: `{"Version": 1, "Remotes": [{"Name": "flathub", "URL": "https://dl.flathub.org/repo/"}],
+				"Apps": [{"Ref": "app/org.gnome.Calculator/x86_64/stable", "Remote": "flathub", "Installation": "user"}]}`,
+			want: []string{"system", "user"},
+		},
+		{
+			name:         "other installation",
+			data:         `{"Version": 1, "Apps": [{"Ref": "app/org.gnome.Calculator/x86_64/stable", "Installation": "system"}]}`,
+			installation: "user",
+			wantErr:      true,
+		},
+		{
+			name:    "unknown installation",
+			data:    `{"Version": 1, "Remotes": [{"Name": "flathub", "URL": "https://dl.flathub.org/repo/", "Installation": "nope"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid ref",
+			data:    `{"Version": 1, "Apps": [{"Ref": "app/", "Remote": "flathub"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "newer version",
+			data:    `{"Version": 2}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := path.Join(t.TempDir(), "state.json")
+			if err := os.WriteFile(file, []byte(tt.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			state, err := loadState(file, tt.installation)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			got := []string{}
+			for _, r := range state.Remotes {
+				got = append(got, r.Installation)
+			}
+			for _, a := range state.Apps {
+				got = append(got, a.Installation)
+			}
+			for _, m := range state.Masks {
+				got = append(got, m.Installation)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("installations = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
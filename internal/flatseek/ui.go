@@ -1,6 +1,7 @@
 package flatseek
 
 import (
+	"fmt"
 	"io"
 	"runtime"
 	"sync"
@@ -27,8 +28,9 @@ const (
 
 // UI is holding our application information and all tview components
 type UI struct {
-	conf *config.Settings
-	app  *tview.Application
+	conf    *config.Settings
+	app     *tview.Application
+	backend Backend
 
 	flexRoot      *tview.Flex
 	flexLeft      *tview.Flex
@@ -41,8 +43,10 @@ type UI struct {
 	tableDetails  *tview.Table
 	spinner       *tview.TextView
 	formSettings  *tview.Form
+	formDialog    *tview.Form
 	textMessage   *tview.TextView
 	textPkgbuild  *tview.TextView
+	treeDeps      *tview.TreeView
 	prevComponent tview.Primitive
 	tableNews     *tview.Table
 
@@ -57,6 +61,7 @@ type UI struct {
 	cacheInfo       *cache.Cache
 	cacheSearch     *cache.Cache
 	cachePkgbuild   *cache.Cache
+	installed       *installedRefs
 	filterRepos     []string
 	asciiMode       bool
 	shell           string
@@ -65,10 +70,17 @@ type UI struct {
 	sortAscending   bool
 	isArm           bool
 	flags           args.Flags
+	installation    string
 
 	tableDetailsMore bool
 
-	pkgbuildWriter io.Writer
+	pkgbuildWriter  io.Writer
+	sourceFiles     []sourceFile
+	sourceSearch    string
+	sourceMatch     int
+	sourceSearching bool
+
+	instancesGeneration int
 }
 
 // New creates a UI object and makes sure everything is initialized
@@ -83,6 +95,7 @@ func New(conf *config.Settings, flags args.Flags) (*UI, error) {
 		cacheInfo:       cache.New(time.Duration(conf.CacheExpiry)*time.Minute, 1*time.Minute),
 		cacheSearch:     cache.New(time.Duration(conf.CacheExpiry)*time.Minute, 1*time.Minute),
 		cachePkgbuild:   cache.New(time.Duration(conf.CacheExpiry)*time.Minute, 1*time.Minute),
+		installed:       newInstalledRefs(),
 
 		flags:         flags,
 		sortAscending: true,
@@ -92,6 +105,18 @@ func New(conf *config.Settings, flags args.Flags) (*UI, error) {
 	// get users default shell
 	ui.shell = util.Shell()
 
+	backend, err := newBackend(conf, flags)
+	if err != nil {
+		return nil, err
+	}
+	ui.backend = backend
+
+	// the installation given as argument is only used for this session and not saved
+	ui.installation = conf.Installation
+	if flags.Installation != "" {
+		ui.installation = flags.Installation
+	}
+
 	// set window layout
 	if conf.SaveWindowLayout {
 		if conf.LeftProportion < 1 || conf.LeftProportion > 9 {
@@ -120,9 +145,39 @@ func New(conf *config.Settings, flags args.Flags) (*UI, error) {
 	return &ui, nil
 }
 
+// creates the backend for our flags and limits it to the configured installation
+func newBackend(conf *config.Settings, flags args.Flags) (Backend, error) {
+	var backend Backend
+
+	// use fixtures instead of flatpak if requested
+	if flags.Fixtures != "" {
+		fake, err := newFakeBackend(flags.Fixtures)
+		if err != nil {
+			return nil, err
+		}
+		backend = fake
+	} else {
+		backend = newCLIBackend(conf)
+	}
+
+	// limit operations to an installation, the one given as argument takes precedence
+	installation := conf.Installation
+	if flags.Installation != "" {
+		if util.IndexOf(installationIDs(), flags.Installation) < 0 {
+			return nil, fmt.Errorf("unknown installation: %s", flags.Installation)
+		}
+		installation = flags.Installation
+	}
+	backend.SetInstallation(installation)
+
+	return backend, nil
+}
+
 // Start runs application / event-loop
 func (ps *UI) Start() error {
-	if ps.flags.SearchTerm != "" {
+	if ps.flags.BundleFile != "" {
+		ps.displayBundle(ps.flags.BundleFile)
+	} else if ps.flags.SearchTerm != "" {
 		ps.inputSearch.SetText(ps.flags.SearchTerm)
 		ps.displayPackages(ps.flags.SearchTerm)
 	} else {
@@ -0,0 +1,315 @@
+package flatseek
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/alecthomas/chroma"
+	"github.com/alecthomas/chroma/formatters"
+	"github.com/alecthomas/chroma/lexers"
+	"github.com/alecthomas/chroma/styles"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// searchMatch is the token type we give to search matches so they get their own style
+const searchMatch chroma.TokenType = -9000
+
+// sourceFile is a file shown in our metadata / manifest viewer
+type sourceFile struct {
+	Title   string
+	Lexer   string
+	Content string
+}
+
+// returns the start and end offsets of all case-insensitive occurrences of a search-term.
+// we compare rune by rune since case folding may change the length of the text
+func findMatches(content, term string) [][2]int {
+	matches := [][2]int{}
+	if term == "" {
+		return matches
+	}
+	for i := 0; i < len(content); {
+		if n, ok := prefixFold(content[i:], term); ok {
+			matches = append(matches, [2]int{i, i + n})
+			i += n
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(content[i:])
+		i += size
+	}
+	return matches
+}
+
+// returns the length of the prefix of s which equals term ignoring case
+func prefixFold(s, term string) (int, bool) {
+	n := 0
+	for _, t := range term {
+		if n >= len(s) {
+			return 0, false
+		}
+		r, size := utf8.DecodeRuneInString(s[n:])
+		if !equalFoldRune(r, t) {
+			return 0, false
+		}
+		n += size
+	}
+	return n, true
+}
+
+// checks if two runes are the same ignoring case
+func equalFoldRune(a, b rune) bool {
+	if a == b {
+		return true
+	}
+	for f := unicode.SimpleFold(a); f != a; f = unicode.SimpleFold(f) {
+		if f == b {
+			return true
+		}
+	}
+	return false
+}
+
+// wraps a token iterator: tokens are split at search matches which get our searchMatch type,
+// and their values are escaped so brackets (like [Application]) are not taken as color tags
+func matchIterator(it chroma.Iterator, matches [][2]int) chroma.Iterator {
+	pos := 0
+	pending := []chroma.Token{}
+	return func() chroma.Token {
+		for len(pending) == 0 {
+			t := it()
+			if t == chroma.EOF {
+				return t
+			}
+			start, end := pos, pos+len(t.Value)
+			pos = end
+			// split the token at the boundaries of the matches within it
+			cut := start
+			for _, m := range matches {
+				if m[1] <= start || m[0] >= end {
+					continue
+				}
+				from, to := max(m[0], start), min(m[1], end)
+				if from > cut {
+					pending = append(pending, chroma.Token{Type: t.Type, Value: t.Value[cut-start : from-start]})
+				}
+				pending = append(pending, chroma.Token{Type: searchMatch, Value: t.Value[from-start : to-start]})
+				cut = to
+			}
+			if cut < end {
+				pending = append(pending, chroma.Token{Type: t.Type, Value: t.Value[cut-start:]})
+			}
+		}
+		t := pending[0]
+		pending = pending[1:]
+		t.Value = tview.Escape(t.Value)
+		return t
+	}
+}
+
+// writes a syntax-highlighted file with its search matches marked
+func highlightSource(w io.Writer, file sourceFile, styleName string, matches [][2]int) error {
+	lexer := lexers.Get(file.Lexer)
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	it, err := chroma.Coalesce(lexer).Tokenise(nil, file.Content)
+	if err != nil {
+		return err
+	}
+	style, err := styles.Get(styleName).Builder().
+		Add(searchMatch, "bold bg:#ffff00 #000000").
+		Build()
+	if err != nil {
+		return err
+	}
+	return formatters.TTY256.Format(w, style, matchIterator(it, matches))
+}
+
+// returns the lexer for a manifest which may be JSON or YAML
+func manifestLexer(manifest string) string {
+	if strings.HasPrefix(strings.TrimSpace(manifest), "{") {
+		return "json"
+	}
+	return "yaml"
+}
+
+// checks if the viewer is shown
+func (ps *UI) sourceVisible() bool {
+	return ps.flexRight.GetItem(0) == ps.textPkgbuild
+}
+
+// shows the metadata of the selected package and, for installed apps, the manifest it was built with
+func (ps *UI) displaySource() {
+	if ps.selectedPackage == nil {
+		return
+	}
+	pkg := *ps.selectedPackage
+	if ipkg, found := ps.getInstalledRef(pkg); found {
+		pkg = ipkg
+	}
+	key := pkg.key()
+
+	ps.sourceSearch = ""
+	ps.sourceMatch = 0
+	ps.sourceSearching = false
+
+	if files, found := ps.cachePkgbuild.Get(key); found {
+		ps.sourceFiles = files.([]sourceFile)
+		ps.drawSource(pkg.ID)
+		return
+	}
+
+	ps.tableDetails.SetTitle(" [::b]" + pkg.ID + " - Retrieving metadata... ")
+	go func() {
+		ps.locker.Lock()
+		ps.startSpinner()
+		defer func() {
+			ps.locker.Unlock()
+			ps.stopSpinner()
+		}()
+
+		files := []sourceFile{}
+		metadata, err := ps.backend.Metadata(pkg)
+		if err == nil {
+			files = append(files, sourceFile{Title: "metadata", Lexer: "ini", Content: metadata})
+			if pkg.IsInstalled && pkg.Kind != "runtime" {
+				// not all apps ship their manifest
+				if manifest, err := ps.backend.Manifest(pkg); err == nil {
+					files = append(files, sourceFile{Title: "manifest", Lexer: manifestLexer(manifest), Content: manifest})
+				}
+			}
+		}
+
+		ps.app.QueueUpdateDraw(func() {
+			if err != nil {
+				ps.tableDetails.SetTitle(" [::b]Error ")
+				ps.displayMessage(err.Error(), true)
+				return
+			}
+			if !ps.conf.DisableCache {
+				ps.cachePkgbuild.Set(key, files, time.Duration(ps.conf.CacheExpiry)*time.Minute)
+			}
+			ps.sourceFiles = files
+			ps.drawSource(pkg.ID)
+		})
+	}()
+}
+
+// draws our files into the viewer and scrolls to the current search match
+func (ps *UI) drawSource(id string) {
+	ps.textPkgbuild.Clear()
+	matchLines := []int{}
+	line := 0
+	for i, f := range ps.sourceFiles {
+		if i > 0 {
+			fmt.Fprint(ps.pkgbuildWriter, "\n\n")
+			line += 2
+		}
+		fmt.Fprintf(ps.pkgbuildWriter, "\033[1m# %s\033[0m\n\n", f.Title)
+		line += 2
+
+		matches := findMatches(f.Content, ps.sourceSearch)
+		for _, m := range matches {
+			matchLines = append(matchLines, line+strings.Count(f.Content[:m[0]], "\n"))
+		}
+		if err := highlightSource(ps.pkgbuildWriter, f, ps.conf.Colors().StylePKGBUILD, matches); err != nil {
+			fmt.Fprint(ps.pkgbuildWriter, tview.Escape(f.Content))
+		}
+		line += strings.Count(f.Content, "\n")
+	}
+
+	// title with the search-term and match position
+	title := " [::b]" + ps.conf.Glyphs().Pkgbuild + id + " "
+	switch {
+	case ps.sourceSearching:
+		title += "- /" + tview.Escape(ps.sourceSearch) + "_ "
+	case ps.sourceSearch != "":
+		title += "- /" + tview.Escape(ps.sourceSearch) + " "
+	}
+	if ps.sourceSearch != "" {
+		if len(matchLines) == 0 {
+			title += "[red](no matches) "
+		} else {
+			ps.sourceMatch = (ps.sourceMatch%len(matchLines) + len(matchLines)) % len(matchLines)
+			title += fmt.Sprintf("(%d/%d) ", ps.sourceMatch+1, len(matchLines))
+		}
+	}
+	ps.textPkgbuild.SetTitle(title)
+
+	if ps.sourceSearch != "" && len(matchLines) > 0 {
+		_, _, _, height := ps.textPkgbuild.GetInnerRect()
+		ps.textPkgbuild.ScrollTo(max(matchLines[ps.sourceMatch]-height/2, 0), 0)
+	} else if !ps.sourceSearching {
+		ps.textPkgbuild.ScrollToBeginning()
+	}
+
+	if !ps.sourceVisible() {
+		ps.flexRight.Clear()
+		ps.flexRight.AddItem(ps.textPkgbuild, 0, 1, false)
+		ps.app.SetFocus(ps.textPkgbuild)
+	}
+}
+
+// closes the viewer and shows the package details again
+func (ps *UI) closeSource() {
+	ps.flexRight.Clear()
+	ps.flexRight.AddItem(ps.tableDetails, 0, 1, false)
+	ps.app.SetFocus(ps.tablePackages)
+	if ps.selectedPackage != nil {
+		ps.drawPackageInfo(*ps.selectedPackage, ps.width)
+	}
+}
+
+// handles the keys of the viewer: "/" starts an incremental search, "n" / "N" jump to the next / previous match
+func (ps *UI) sourceInputCapture(event *tcell.EventKey) *tcell.EventKey {
+	id := ""
+	if ps.selectedPackage != nil {
+		id = ps.selectedPackage.ID
+	}
+
+	if ps.sourceSearching {
+		switch event.Key() {
+		case tcell.KeyEnter:
+			ps.sourceSearching = false
+		case tcell.KeyEscape:
+			ps.sourceSearching = false
+			ps.sourceSearch = ""
+		case tcell.KeyBackspace, tcell.KeyBackspace2:
+			if len(ps.sourceSearch) > 0 {
+				runes := []rune(ps.sourceSearch)
+				ps.sourceSearch = string(runes[:len(runes)-1])
+			}
+			ps.sourceMatch = 0
+		case tcell.KeyRune:
+			ps.sourceSearch += string(event.Rune())
+			ps.sourceMatch = 0
+		default:
+			return event
+		}
+		ps.drawSource(id)
+		return nil
+	}
+
+	switch {
+	case event.Key() == tcell.KeyEscape:
+		ps.closeSource()
+		return nil
+	case event.Rune() == '/':
+		ps.sourceSearching = true
+		ps.sourceSearch = ""
+		ps.sourceMatch = 0
+	case event.Rune() == 'n' && ps.sourceSearch != "":
+		ps.sourceMatch++
+	case event.Rune() == 'N' && ps.sourceSearch != "":
+		ps.sourceMatch--
+	default:
+		return event
+	}
+	ps.drawSource(id)
+	return nil
+}
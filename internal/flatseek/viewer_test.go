@@ -0,0 +1,30 @@
+package flatseek
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFindMatches(t *testing.T) {
+	tests := []struct {
+		content string
+		term    string
+		want    [][2]int
+	}{
+		{"[Application]\nname=org.x.Y\n", "", [][2]int{}},
+		{"[Application]\nname=org.x.Y\n", "APP", [][2]int{{1, 4}}},
+		{"name=org.x.Y;runtime=org.x.Platform", "org.x", [][2]int{{5, 10}, {21, 26}}},
+		{"aaaa", "aa", [][2]int{{0, 2}, {2, 4}}},
+		// the dotted capital I lowercases to two runes, the kelvin sign is longer than the k it folds to
+		{"\u0130stanbul: \u0130", "i", [][2]int{}},
+		{"\u0130stanbul: stanbul", "STANBUL", [][2]int{{2, 9}, {11, 18}}},
+		{"500 \u212a", "k", [][2]int{{4, 7}}},
+		{"name", "namespace", [][2]int{}},
+	}
+	for _, tt := range tests {
+		got := findMatches(tt.content, tt.term)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("findMatches(%q, %q) = %v, want %v", tt.content, tt.term, got, tt.want)
+		}
+	}
+}
@@ -11,12 +11,20 @@ import (
 )
 
 const helpText = `
-Usage: flatseek [OPTION] [SEARCH-TERM]
+Usage: flatseek [OPTION] [SEARCH-TERM | FILE.flatpak | FILE.flatpakref | FILE.flatpakrepo]
+       flatseek apply [OPTION] FILE
 	-s	Search-term
 	-a	ASCII mode
 	-m	Monochrome mode
 	-u	show upgrades after startup
 	-i	show installed packages after startup
+	--installation NAME	limit operations to an installation (user, system or custom)
+	--fixtures DIR	use fixture files instead of flatpak (for testing)
+	--dry-run	only print the plan of apply
+
+apply converges the remotes, apps, overrides and masks to a state file
+non-interactively. Exit code: 0 if nothing had to change, 2 if changes
+were planned or applied, 1 on errors.
 
 `
 
@@ -46,6 +54,17 @@ func main() {
 			printErrorExit("Error loading configuration file", err)
 		}
 	}
+	if f.Command == "apply" {
+		drift, err := flatseek.Apply(conf, f)
+		if err != nil {
+			printErrorExit("Error applying "+f.StateFile, err)
+		}
+		if drift {
+			os.Exit(2)
+		}
+		os.Exit(0)
+	}
+
 	ps, err := flatseek.New(conf, f)
 	if err != nil {
 		printErrorExit("Error during flatseek initialization", err)